@@ -3,14 +3,24 @@ package flags
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/daemonp/gogpt/pkg/config"
 	"github.com/daemonp/gogpt/pkg/types"
 )
 
 func ParseFlags() *types.Flags {
 	var excludePaths string
+	var includePaths string
 	var maxTokens int
+	var budget int
+	var priorityGlobs string
+	var chunkTokens int
+	var jobs int
 
 	flags := &types.Flags{}
 
@@ -20,17 +30,189 @@ func ParseFlags() *types.Flags {
 	flag.IntVar(&maxTokens, "max-tokens", 0, "Maximum number of tokens per file (default: no limit)")
 	flag.BoolVar(&flags.Verbose, "v", false, "Enable verbose logging")
 	flag.StringVar(&flags.ExcludePattern, "exclude", "", "Regex pattern to exclude lines (e.g., '^\\s*//')")
-	flag.StringVar(&excludePaths, "x", "", "Comma-separated list of paths to exclude")
+	flag.StringVar(&excludePaths, "x", "", "Comma-separated gitignore-style globs of paths to exclude (e.g., 'vendor/**,!vendor/keep/**')")
+	flag.StringVar(&excludePaths, "exclude-path", "", "Same as -x")
+	flag.StringVar(&includePaths, "include", "", "Comma-separated gitignore-style globs; only matching paths are exported (e.g., 'cmd/**,pkg/**')")
+	flag.BoolVar(&flags.SubstringExclude, "substring-exclude", false, "Match -x paths as plain substrings of the path, as older versions did")
+	flag.BoolVar(&flags.IncludeGenerated, "include-generated", false, "Include generated and minified files and lockfiles, which are skipped by default")
+	flag.BoolVar(&flags.IncludeVendored, "include-vendored", false, "Include files in vendored directories like vendor and node_modules, which are skipped by default")
+	flag.BoolVar(&flags.StripComments, "strip-comments", false, "Remove comments from Go, JS/TS, Python, C-family, shell and YAML files")
+	flag.BoolVar(&flags.CollapseBlankLines, "collapse-blank-lines", false, "Collapse runs of blank lines and remove trailing whitespace")
+	flag.BoolVar(&flags.KeepDocComments, "keep-doc-comments", false, "Keep doc comments and docstrings with --strip-comments")
+	flag.BoolVar(&flags.Signatures, "signatures", false, "Export only the declarations and doc comments of Go, Python, JS/TS and Java files, without function bodies")
+	flag.StringVar(&flags.Order, "order", "", "Order of the files in the export: path, size, tokens, mtime or dependency (default: path)")
+	flag.BoolVar(&flags.TreeAnnotate, "tree-annotate", false, "Show the size and token count of each file in the tree, and mark files excluded by size")
+	flag.BoolVar(&flags.TreeFiltered, "tree-filtered", false, "Show files left out by .gitignore, the filters or the classifier in the tree, marked as such")
+	flag.BoolVar(&flags.TreeOnly, "tree-only", false, "Output only the directory structure")
+	flag.StringVar(&flags.Oversize, "oversize", "", "What to do with files over --max-tokens: exclude, head, head-tail or outline (default: exclude)")
+	flag.IntVar(&budget, "budget", 0, "Maximum number of tokens for the whole export (default: no limit)")
+	flag.StringVar(&flags.PackStrategy, "pack", "", "Order in which files are packed into the budget: size or depth (default: size)")
+	flag.StringVar(&priorityGlobs, "priority", "", "Comma-separated globs of files to pack into the budget first (e.g., 'cmd/*,*.go')")
+	flag.IntVar(&chunkTokens, "chunk-tokens", 0, "Split the export into numbered parts of at most this many tokens (default: single output)")
+	flag.StringVar(&flags.Format, "format", "", "Output format: markdown, xml, json or jsonl (default: markdown)")
+	flag.StringVar(&flags.Since, "since", "", "Only export files changed since a git ref, including uncommitted changes and untracked files")
+	flag.BoolVar(&flags.Staged, "staged", false, "Only export files with staged changes, as they are in the index")
+	flag.StringVar(&flags.Commit, "commit", "", "Only export files changed by a single commit, as they are in that commit")
+	flag.BoolVar(&flags.IncludeDiff, "diff", false, "Include the unified diff of each changed file (with --since, --staged or --commit)")
+	flag.StringVar(&flags.Rev, "rev", "", "Export a git branch, tag or commit from the object database instead of the working tree")
+	flag.StringVar(&flags.Profile, "profile", "", "Named profile from .gogpt.yaml or .gogpt.toml to apply")
+	flag.IntVar(&jobs, "jobs", 0, "Number of files to process at once (default: number of CPUs)")
+	flag.DurationVar(&flags.ReadTimeout, "read-timeout", 0, "Give up reading a file after this long, e.g. for FIFOs or slow network mounts (default: 30s)")
+	flag.BoolVar(&flags.Strict, "strict", false, "Fail without writing the export if any file cannot be read")
+	flag.StringVar(&flags.Encoding, "encoding", "", "Tokenizer encoding used to count tokens: cl100k_base or o200k_base (default: cl100k_base)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [options] [path ...]\n\nExports the current directory, or each given path as its own root.\n\nOptions:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
 
 	flag.Parse()
 
+	if err := applyConfig(flags.Profile); err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "%v\n", err)
+		os.Exit(2)
+	}
+
+	if flag.NArg() > 0 {
+		flags.Roots = flag.Args()
+	}
+
 	if excludePaths != "" {
 		flags.ExcludePaths = strings.Split(excludePaths, ",")
 	}
 
+	if includePaths != "" {
+		flags.IncludePaths = strings.Split(includePaths, ",")
+	}
+
 	if maxTokens > 0 {
 		flags.MaxTokens = &maxTokens
 	}
 
+	if budget > 0 {
+		flags.Budget = &budget
+	}
+
+	if chunkTokens > 0 {
+		flags.ChunkTokens = &chunkTokens
+	}
+
+	if jobs > 0 {
+		flags.Jobs = &jobs
+	}
+
+	if priorityGlobs != "" {
+		flags.PriorityGlobs = strings.Split(priorityGlobs, ",")
+	}
+
 	return flags
 }
+
+// flagAliases maps the long form of a flag to the short form it shares a
+// variable with.
+var flagAliases = map[string]string{
+	"exclude-path": "x",
+}
+
+// applyConfig sets every flag that was not given on the command line from
+// the config files, so command line flags take precedence over the selected
+// profile, which takes precedence over the files' top-level settings. The
+// project config is looked up from the first root, or the current directory
+// when none is given.
+func applyConfig(profile string) error {
+	dir := flag.Arg(0)
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("failed to get current working directory: %w", err)
+		}
+	}
+
+	settings, _, err := config.Load(dir, profile)
+	if err != nil {
+		return err
+	}
+
+	// Config values are keyed by the short form of flags that have two.
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+		if short, ok := flagAliases[f.Name]; ok {
+			set[short] = true
+		}
+	})
+
+	for name, value := range configValues(settings) {
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config value for %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// configValues maps the config settings that are set to flag values.
+func configValues(settings config.Settings) map[string]string {
+	values := make(map[string]string)
+
+	setString := func(name string, value *string) {
+		if value != nil {
+			values[name] = *value
+		}
+	}
+	setBool := func(name string, value *bool) {
+		if value != nil {
+			values[name] = strconv.FormatBool(*value)
+		}
+	}
+	setInt := func(name string, value *int) {
+		if value != nil {
+			values[name] = strconv.Itoa(*value)
+		}
+	}
+	setList := func(name string, value []string) {
+		if value != nil {
+			values[name] = strings.Join(value, ",")
+		}
+	}
+
+	setString("f", settings.Output)
+	setBool("i", settings.GitIgnore)
+	setList("l", settings.Languages)
+	setInt("max-tokens", settings.MaxTokens)
+	setBool("v", settings.Verbose)
+	setString("exclude", settings.Exclude)
+	setList("x", settings.ExcludePaths)
+	setList("include", settings.IncludePaths)
+	setBool("substring-exclude", settings.SubstringExclude)
+	setBool("include-generated", settings.IncludeGenerated)
+	setBool("include-vendored", settings.IncludeVendored)
+	setBool("strip-comments", settings.StripComments)
+	setBool("collapse-blank-lines", settings.CollapseBlankLines)
+	setBool("keep-doc-comments", settings.KeepDocComments)
+	setBool("signatures", settings.Signatures)
+	setString("oversize", settings.Oversize)
+	setString("order", settings.Order)
+	setBool("tree-annotate", settings.TreeAnnotate)
+	setBool("tree-only", settings.TreeOnly)
+	setBool("tree-filtered", settings.TreeFiltered)
+	setInt("jobs", settings.Jobs)
+	setString("read-timeout", settings.ReadTimeout)
+	setBool("strict", settings.Strict)
+	setString("encoding", settings.Encoding)
+	setInt("budget", settings.Budget)
+	setString("pack", settings.Pack)
+	setList("priority", settings.Priority)
+	setInt("chunk-tokens", settings.ChunkTokens)
+	setString("format", settings.Format)
+	setString("since", settings.Since)
+	setBool("staged", settings.Staged)
+	setString("commit", settings.Commit)
+	setBool("diff", settings.Diff)
+	setString("rev", settings.Rev)
+
+	return values
+}
@@ -5,10 +5,13 @@ package flags
 import (
 	"flag"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/daemonp/gogpt/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func intPtr(i int) *int {
@@ -16,6 +19,9 @@ func intPtr(i int) *int {
 }
 
 func TestParseFlags(t *testing.T) {
+	// Keep a user config from leaking into the expected flags.
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
 	tests := []struct {
 		name          string
 		args          []string
@@ -53,13 +59,91 @@ func TestParseFlags(t *testing.T) {
 		},
 		{
 			name: "All flags",
-			args: []string{"cmd", "-v", "-f", "test.txt", "-i=false", "-l=go,js", "--max-tokens=500"},
+			args: []string{"cmd", "-v", "-f", "test.txt", "-i=false", "-l=go,js", "--max-tokens=500", "--encoding=o200k_base", "--format=xml"},
 			expectedFlags: &types.Flags{
 				Verbose:      true,
 				OutputFile:   "test.txt",
 				UseGitIgnore: false,
 				Languages:    "go,js",
 				MaxTokens:    intPtr(500),
+				Encoding:     "o200k_base",
+				Format:       "xml",
+			},
+		},
+		{
+			name: "Budget flags",
+			args: []string{"cmd", "--budget=8000", "--pack=depth", "--priority=cmd/*,*.go"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore:  true,
+				Budget:        intPtr(8000),
+				PackStrategy:  "depth",
+				PriorityGlobs: []string{"cmd/*", "*.go"},
+			},
+		},
+		{
+			name: "Chunk flag",
+			args: []string{"cmd", "-f", "export.md", "--chunk-tokens=32000"},
+			expectedFlags: &types.Flags{
+				OutputFile:   "export.md",
+				UseGitIgnore: true,
+				ChunkTokens:  intPtr(32000),
+			},
+		},
+		{
+			name: "Git flags",
+			args: []string{"cmd", "--since=main", "--diff"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Since:        "main",
+				IncludeDiff:  true,
+			},
+		},
+		{
+			name: "Revision flag",
+			args: []string{"cmd", "--rev=v1.2.0"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Rev:          "v1.2.0",
+			},
+		},
+		{
+			name: "Path flags",
+			args: []string{"cmd", "--include=cmd/**,pkg/**", "--exclude-path=**/*_test.go", "--substring-exclude"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore:     true,
+				IncludePaths:     []string{"cmd/**", "pkg/**"},
+				ExcludePaths:     []string{"**/*_test.go"},
+				SubstringExclude: true,
+			},
+		},
+		{
+			name: "Content flags",
+			args: []string{"cmd", "--include-generated", "--include-vendored", "--strip-comments", "--collapse-blank-lines", "--keep-doc-comments", "--signatures", "--oversize=head-tail", "--order=dependency", "--tree-annotate", "--tree-only", "--tree-filtered", "--jobs=4", "--read-timeout=5s", "--strict"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore:       true,
+				IncludeGenerated:   true,
+				IncludeVendored:    true,
+				StripComments:      true,
+				CollapseBlankLines: true,
+				KeepDocComments:    true,
+				Signatures:         true,
+				Oversize:           "head-tail",
+				Order:              "dependency",
+				TreeAnnotate:       true,
+				TreeOnly:           true,
+				TreeFiltered:       true,
+				Jobs:               intPtr(4),
+				ReadTimeout:        5 * time.Second,
+				Strict:             true,
+			},
+		},
+		{
+			name: "Root paths",
+			args: []string{"cmd", "-l", "go", "./svc", "../shared-lib"},
+			expectedFlags: &types.Flags{
+				Roots:        []string{"./svc", "../shared-lib"},
+				UseGitIgnore: true,
+				Languages:    "go",
 			},
 		},
 	}
@@ -79,3 +163,136 @@ func TestParseFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlagsConfig(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".gogpt.yaml"), []byte(`languages: [go, md]
+exclude_paths: [vendor, testdata]
+max_tokens: 2000
+profiles:
+  review:
+    since: main
+    diff: true
+    max_tokens: 4000
+`), 0644))
+
+	other := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(other, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(other, ".gogpt.yaml"), []byte("languages: [py]\n"), 0644))
+
+	oldDir, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(dir))
+	defer os.Chdir(oldDir)
+
+	tests := []struct {
+		name          string
+		args          []string
+		expectedFlags *types.Flags
+	}{
+		{
+			name: "Config settings",
+			args: []string{"cmd"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Languages:    "go,md",
+				ExcludePaths: []string{"vendor", "testdata"},
+				MaxTokens:    intPtr(2000),
+			},
+		},
+		{
+			name: "Profile overrides settings",
+			args: []string{"cmd", "--profile=review"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Languages:    "go,md",
+				ExcludePaths: []string{"vendor", "testdata"},
+				MaxTokens:    intPtr(4000),
+				Since:        "main",
+				IncludeDiff:  true,
+				Profile:      "review",
+			},
+		},
+		{
+			name: "Command line overrides profile",
+			args: []string{"cmd", "--profile=review", "-l", "go", "--since=v1.0.0"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Languages:    "go",
+				ExcludePaths: []string{"vendor", "testdata"},
+				MaxTokens:    intPtr(4000),
+				Since:        "v1.0.0",
+				IncludeDiff:  true,
+				Profile:      "review",
+			},
+		},
+		{
+			name: "Long form overrides config",
+			args: []string{"cmd", "--exclude-path=b.go"},
+			expectedFlags: &types.Flags{
+				UseGitIgnore: true,
+				Languages:    "go,md",
+				ExcludePaths: []string{"b.go"},
+				MaxTokens:    intPtr(2000),
+			},
+		},
+		{
+			name: "Config of the first root",
+			args: []string{"cmd", other, dir},
+			expectedFlags: &types.Flags{
+				Roots:        []string{other, dir},
+				UseGitIgnore: true,
+				Languages:    "py",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+
+			oldArgs := os.Args
+			defer func() { os.Args = oldArgs }()
+			os.Args = tt.args
+
+			flags := ParseFlags()
+			assert.Equal(t, tt.expectedFlags, flags)
+		})
+	}
+}
+
+func TestParseStatsFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		expectedFlags *types.StatsFlags
+	}{
+		{
+			name: "Default flags",
+			args: nil,
+			expectedFlags: &types.StatsFlags{
+				UseGitIgnore: true,
+				Format:       StatsFormatTable,
+			},
+		},
+		{
+			name: "JSON with roots",
+			args: []string{"--format=json", "--encoding=o200k_base", "./svc", "../lib"},
+			expectedFlags: &types.StatsFlags{
+				Roots:        []string{"./svc", "../lib"},
+				UseGitIgnore: true,
+				Encoding:     "o200k_base",
+				Format:       StatsFormatJSON,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFlags, ParseStatsFlags(tt.args))
+		})
+	}
+}
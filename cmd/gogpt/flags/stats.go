@@ -0,0 +1,44 @@
+// File: cmd/gogpt/flags/stats.go
+package flags
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/daemonp/gogpt/pkg/types"
+)
+
+const (
+	StatsFormatTable = "table"
+	StatsFormatJSON  = "json"
+)
+
+// ParseStatsFlags parses the arguments of `gogpt stats`.
+func ParseStatsFlags(args []string) *types.StatsFlags {
+	flags := &types.StatsFlags{}
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.BoolVar(&flags.UseGitIgnore, "i", true, "Use .gitignore (default: true)")
+	fs.StringVar(&flags.Encoding, "encoding", "", "Tokenizer encoding used to count tokens: cl100k_base or o200k_base (default: cl100k_base)")
+	fs.StringVar(&flags.Format, "format", StatsFormatTable, "Output format: table or json")
+	fs.BoolVar(&flags.Verbose, "v", false, "Enable verbose logging")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s stats [options] [path ...]\n\nPrints per-language statistics for the current directory or the given paths.\n\nOptions:\n", filepath.Base(os.Args[0]))
+		fs.PrintDefaults()
+	}
+
+	fs.Parse(args)
+
+	if flags.Format != StatsFormatTable && flags.Format != StatsFormatJSON {
+		fmt.Fprintf(fs.Output(), "unknown stats format %q (supported: %s, %s)\n", flags.Format, StatsFormatTable, StatsFormatJSON)
+		os.Exit(2)
+	}
+
+	if fs.NArg() > 0 {
+		flags.Roots = fs.Args()
+	}
+
+	return flags
+}
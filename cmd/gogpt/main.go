@@ -3,7 +3,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/daemonp/gogpt/cmd/gogpt/flags"
 	"github.com/daemonp/gogpt/cmd/gogpt/logger"
@@ -14,25 +18,48 @@ import (
 var (
 	osExit         = os.Exit
 	osGetwd        = os.Getwd
-	exporterNew    = exporter.New
+	exporterNew    = exporter.NewWithRoots
 	parseFlagsFunc = flags.ParseFlags
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		runStats(os.Args[2:])
+		return
+	}
+
 	flags := parseFlagsFunc()
 	logger.SetupLogger(flags.Verbose)
 
-	dir, err := osGetwd()
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to get current working directory")
-		osExit(1)
-		return
+	dirs := flags.Roots
+	if len(dirs) == 0 {
+		dir, err := osGetwd()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get current working directory")
+			osExit(1)
+			return
+		}
+
+		log.Debug().Str("dir", dir).Msg("Current working directory")
+		dirs = []string{dir}
 	}
 
-	log.Debug().Str("dir", dir).Msg("Current working directory")
+	// The first interrupt cancels the export; once it is cancelled, the
+	// default handling is restored so a second one ends the process.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	exp, err := exporterNew(dir, flags)
+	exp, err := exporterNew(ctx, dirs, flags)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			log.Warn().Msg("Export interrupted")
+			osExit(130)
+			return
+		}
 		log.Error().Err(err).Msg("Failed to create exporter")
 		osExit(1)
 		return
@@ -44,9 +71,19 @@ func main() {
 		return
 	}
 
-	if err := exp.Export(); err != nil {
-		log.Error().Err(err).Msg("Failed to export repository contents")
-		osExit(1)
+	if err := exp.Export(ctx); err != nil {
+		var scanErr *exporter.ScanError
+		switch {
+		case errors.Is(err, context.Canceled):
+			log.Warn().Msg("Export interrupted")
+			osExit(130)
+		case errors.As(err, &scanErr) && scanErr.Written:
+			log.Warn().Int("failed", len(scanErr.Failed)).Msg("Export completed, but some files could not be read")
+			osExit(2)
+		default:
+			log.Error().Err(err).Msg("Failed to export repository contents")
+			osExit(1)
+		}
 		return
 	}
 
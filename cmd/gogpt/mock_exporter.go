@@ -3,22 +3,24 @@
 package main
 
 import (
+	"context"
+
 	"github.com/daemonp/gogpt/pkg/exporter"
 	"github.com/daemonp/gogpt/pkg/types"
 )
 
 type TestExporter struct {
 	*exporter.Exporter
-	ExportFunc func() error
+	ExportFunc func(ctx context.Context) error
 }
 
-func (te *TestExporter) Export() error {
+func (te *TestExporter) Export(ctx context.Context) error {
 	if te.ExportFunc != nil {
-		return te.ExportFunc()
+		return te.ExportFunc(ctx)
 	}
-	return te.Exporter.Export()
+	return te.Exporter.Export(ctx)
 }
 
-func NewTestExporter(rootDir string, flags *types.Flags) (*exporter.Exporter, error) {
+func NewTestExporter(ctx context.Context, rootDirs []string, flags *types.Flags) (*exporter.Exporter, error) {
 	return &exporter.Exporter{}, nil
 }
@@ -0,0 +1,71 @@
+// File: cmd/gogpt/stats.go
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/daemonp/gogpt/cmd/gogpt/flags"
+	"github.com/daemonp/gogpt/cmd/gogpt/logger"
+	"github.com/daemonp/gogpt/pkg/gitignore"
+	"github.com/daemonp/gogpt/pkg/languagedetector"
+	"github.com/daemonp/gogpt/pkg/tiktoken"
+	"github.com/rs/zerolog/log"
+)
+
+// runStats implements `gogpt stats`, which prints per-language statistics
+// instead of exporting.
+func runStats(args []string) {
+	statsFlags := flags.ParseStatsFlags(args)
+	logger.SetupLogger(statsFlags.Verbose)
+
+	dirs := statsFlags.Roots
+	if len(dirs) == 0 {
+		dir, err := osGetwd()
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to get current working directory")
+			osExit(1)
+			return
+		}
+		dirs = []string{dir}
+	}
+
+	encoding, err := tiktoken.GetEncoding(statsFlags.Encoding)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to load tokenizer")
+		osExit(1)
+		return
+	}
+
+	sets := make([]languagedetector.Stats, 0, len(dirs))
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err != nil {
+			log.Error().Err(err).Msg("Failed to read directory")
+			osExit(1)
+			return
+		}
+
+		var ignore func(path string) bool
+		if statsFlags.UseGitIgnore {
+			if gitIgnore, err := gitignore.NewGitIgnore(dir); err != nil {
+				log.Warn().Err(err).Msg("Failed to parse .gitignore files, continuing without gitignore")
+			} else {
+				ignore = gitIgnore.ShouldIgnore
+			}
+		}
+
+		sets = append(sets, languagedetector.DetectLanguages(os.DirFS(dir), ignore, encoding.Count))
+	}
+	stats := languagedetector.MergeStats(sets...)
+
+	if statsFlags.Format == flags.StatsFormatJSON {
+		err = stats.WriteJSON(os.Stdout)
+	} else {
+		err = stats.WriteTable(os.Stdout)
+	}
+	if err != nil {
+		log.Error().Err(fmt.Errorf("failed to write stats: %w", err)).Msg("Failed to print language statistics")
+		osExit(1)
+	}
+}
@@ -0,0 +1,197 @@
+// File: pkg/classifier/classifier.go
+
+package classifier
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	Binary    = "binary"
+	Minified  = "minified"
+	Generated = "generated"
+	Vendored  = "vendored"
+)
+
+const (
+	// sniffLen is how much of a file is checked for binary content, as in git.
+	sniffLen = 8000
+	// maxInvalidRatio is the share of invalid UTF-8 bytes above which a file
+	// is binary. A few are tolerated for text in legacy encodings.
+	maxInvalidRatio = 0.1
+
+	minMinifiedSize       = 2048
+	minifiedAverageLine   = 500
+	minifiedLongestLine   = 10000
+	generatedHeaderLines  = 30
+	generatedHeaderMaxLen = 4096
+)
+
+// vendorDirs are directory names holding third-party code.
+var vendorDirs = map[string]bool{
+	"vendor":           true,
+	"node_modules":     true,
+	"third_party":      true,
+	"third-party":      true,
+	"bower_components": true,
+	"jspm_packages":    true,
+	"Pods":             true,
+	"Carthage":         true,
+	".yarn":            true,
+}
+
+// lockfiles are generated by package managers.
+var lockfiles = map[string]bool{
+	"package-lock.json":   true,
+	"npm-shrinkwrap.json": true,
+	"yarn.lock":           true,
+	"pnpm-lock.yaml":      true,
+	"bun.lockb":           true,
+	"go.sum":              true,
+	"Cargo.lock":          true,
+	"Gemfile.lock":        true,
+	"poetry.lock":         true,
+	"Pipfile.lock":        true,
+	"composer.lock":       true,
+	"Podfile.lock":        true,
+	"mix.lock":            true,
+	"flake.lock":          true,
+}
+
+var (
+	goGenerated      = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+	generatedMarkers = []string{"@generated", "<auto-generated"}
+	// commentPrefixes start the comment lines of the supported languages.
+	commentPrefixes = []string{"//", "/*", "*", "#", "<!--", "--", ";", "%"}
+)
+
+// Classify returns why a file should not be exported: Binary, Minified or
+// Generated, or "" for an ordinary file. Vendored files are recognized by
+// their directory with VendoredDir.
+func Classify(filePath string, content []byte) string {
+	switch {
+	case IsBinary(content):
+		return Binary
+	case IsGenerated(filePath, content):
+		return Generated
+	case IsMinified(filePath, content):
+		return Minified
+	default:
+		return ""
+	}
+}
+
+// IsBinary reports whether content contains a NUL byte or too much invalid
+// UTF-8 near its start.
+func IsBinary(content []byte) bool {
+	sample := content[:min(len(content), sniffLen)]
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true
+	}
+
+	invalid := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		if r == utf8.RuneError && size == 1 {
+			// A rune cut off by the end of the sample is not invalid.
+			if len(sample) < len(content) && !utf8.FullRune(sample[i:]) {
+				break
+			}
+			invalid++
+		}
+		i += size
+	}
+	return len(sample) > 0 && float64(invalid)/float64(len(sample)) > maxInvalidRatio
+}
+
+// IsMinified reports whether a file is minified: named *.min.* or made of
+// very long lines.
+func IsMinified(filePath string, content []byte) bool {
+	if strings.Contains(path.Base(filePath), ".min.") {
+		return true
+	}
+	if len(content) < minMinifiedSize {
+		return false
+	}
+
+	lines, longest, current := 0, 0, 0
+	for _, b := range content {
+		if b == '\n' {
+			lines++
+			longest = max(longest, current)
+			current = 0
+			continue
+		}
+		current++
+	}
+	if current > 0 {
+		lines++
+		longest = max(longest, current)
+	}
+
+	return len(content)/lines > minifiedAverageLine || longest > minifiedLongestLine
+}
+
+// IsGenerated reports whether a file is a lockfile or has a generated-code
+// marker in a comment of its header: Go's "// Code generated ... DO NOT
+// EDIT." line, "@generated" or "<auto-generated".
+func IsGenerated(filePath string, content []byte) bool {
+	if lockfiles[path.Base(filePath)] {
+		return true
+	}
+
+	header := content[:min(len(content), generatedHeaderMaxLen)]
+	if lines := bytes.SplitN(header, []byte("\n"), generatedHeaderLines+1); len(lines) > generatedHeaderLines {
+		header = bytes.Join(lines[:generatedHeaderLines], []byte("\n"))
+	}
+
+	for _, line := range bytes.Split(header, []byte("\n")) {
+		line = bytes.TrimSuffix(line, []byte("\r"))
+		if goGenerated.Match(line) {
+			return true
+		}
+		if !isComment(line) {
+			continue
+		}
+		for _, marker := range generatedMarkers {
+			if bytes.Contains(line, []byte(marker)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isComment reports whether a line is a comment, or part of a block comment
+// starting with "*".
+func isComment(line []byte) bool {
+	line = bytes.TrimSpace(line)
+	for _, prefix := range commentPrefixes {
+		if bytes.HasPrefix(line, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsVendorDir reports whether a directory name holds third-party code.
+func IsVendorDir(name string) bool {
+	return vendorDirs[name]
+}
+
+// VendoredDir returns the vendored directory a slash-separated relative path
+// is in, e.g. "web/node_modules" for "web/node_modules/react/index.js", or
+// "" if it is not vendored.
+func VendoredDir(relPath string) string {
+	parts := strings.Split(relPath, "/")
+	for i, part := range parts[:len(parts)-1] {
+		if vendorDirs[part] {
+			return strings.Join(parts[:i+1], "/")
+		}
+	}
+	return ""
+}
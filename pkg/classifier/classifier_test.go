@@ -0,0 +1,66 @@
+// File: pkg/classifier/classifier_test.go
+
+package classifier
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{"Source file", "main.go", "package main\n\nfunc main() {}\n", ""},
+		{"Empty file", "empty.go", "", ""},
+		{"NUL byte", "logo.go", "GIF89a\x00\x01", Binary},
+		{"Invalid UTF-8", "latin.txt", strings.Repeat("\xff\xfe", 100), Binary},
+		{"Some invalid UTF-8 is text", "legacy.txt", "caf\xe9 " + strings.Repeat("ordinary text ", 20), ""},
+		{"Rune cut off by the sample", "utf8.md", strings.Repeat("abcdefg\n", sniffLen/8-1) + "abcdefg" + "é", ""},
+		{"Go generated marker", "api.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n", Generated},
+		{"Generated marker after license", "gen.go", strings.Repeat("// License line\n", 10) + "// Code generated by stringer; DO NOT EDIT.\n", Generated},
+		{"Generated marker too late", "late.go", strings.Repeat("\n", 40) + "// DO NOT EDIT\n", ""},
+		{"Generated annotation", "Schema.java", "/* @generated */\nclass Schema {}\n", Generated},
+		{"Generated annotation in a block comment", "Api.cs", "/*\n * <auto-generated />\n */\nclass Api {}\n", Generated},
+		{"Go marker in a string literal", "gen_test.go", "package gen\n\nconst header = \"// Code generated by gen. DO NOT EDIT.\\n\"\n", ""},
+		{"Markers in string literals", "markers.go", "package gen\n\nvar markers = []string{\"DO NOT EDIT\", \"@generated\", \"<auto-generated\"}\n", ""},
+		{"Go marker without its period", "stub.go", "// Code generated by hand, DO NOT EDIT\npackage stub\n", ""},
+		{"Lockfile", "web/package-lock.json", "{}\n", Generated},
+		{"Go checksums", "go.sum", "example.com/x v1.0.0 h1:abc=\n", Generated},
+		{"Minified name", "dist/app.min.js", "x()\n", Minified},
+		{"Long lines", "bundle.js", strings.Repeat("a;", 3000), Minified},
+		{"Long line in a long file", "data.js", strings.Repeat("x = 1\n", 200) + strings.Repeat("y", minifiedLongestLine+1), Minified},
+		{"Ordinary long file", "big.py", strings.Repeat("print('hello, world')\n", 500), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Classify(tt.path, []byte(tt.content)))
+		})
+	}
+}
+
+func TestVendoredDir(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"vendor/github.com/x/y.go", "vendor"},
+		{"web/node_modules/react/index.js", "web/node_modules"},
+		{"third_party/a/node_modules/b.js", "third_party"},
+		{"pkg/vendor.go", ""},
+		{"vendor", ""},
+		{"src/main.go", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, VendoredDir(tt.path))
+		})
+	}
+}
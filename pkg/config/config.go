@@ -0,0 +1,258 @@
+// File: pkg/config/config.go
+
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings holds the options a config file can set. Unset options are nil so
+// they can be told apart from zero values when files are merged.
+type Settings struct {
+	Output       *string  `yaml:"output" toml:"output"`
+	GitIgnore    *bool    `yaml:"gitignore" toml:"gitignore"`
+	Languages    []string `yaml:"languages" toml:"languages"`
+	MaxTokens    *int     `yaml:"max_tokens" toml:"max_tokens"`
+	Verbose      *bool    `yaml:"verbose" toml:"verbose"`
+	Exclude      *string  `yaml:"exclude" toml:"exclude"`
+	ExcludePaths []string `yaml:"exclude_paths" toml:"exclude_paths"`
+	IncludePaths []string `yaml:"include" toml:"include"`
+	// SubstringExclude matches exclude_paths as substrings instead of globs.
+	SubstringExclude *bool    `yaml:"substring_exclude" toml:"substring_exclude"`
+	IncludeGenerated *bool    `yaml:"include_generated" toml:"include_generated"`
+	IncludeVendored  *bool    `yaml:"include_vendored" toml:"include_vendored"`
+	Encoding         *string  `yaml:"encoding" toml:"encoding"`
+	Budget           *int     `yaml:"budget" toml:"budget"`
+	Pack             *string  `yaml:"pack" toml:"pack"`
+	Priority         []string `yaml:"priority" toml:"priority"`
+	ChunkTokens      *int     `yaml:"chunk_tokens" toml:"chunk_tokens"`
+	Format           *string  `yaml:"format" toml:"format"`
+	Since            *string  `yaml:"since" toml:"since"`
+	Staged           *bool    `yaml:"staged" toml:"staged"`
+	Commit           *string  `yaml:"commit" toml:"commit"`
+	Diff             *bool    `yaml:"diff" toml:"diff"`
+	Rev              *string  `yaml:"rev" toml:"rev"`
+
+	StripComments      *bool   `yaml:"strip_comments" toml:"strip_comments"`
+	CollapseBlankLines *bool   `yaml:"collapse_blank_lines" toml:"collapse_blank_lines"`
+	KeepDocComments    *bool   `yaml:"keep_doc_comments" toml:"keep_doc_comments"`
+	Signatures         *bool   `yaml:"signatures" toml:"signatures"`
+	Oversize           *string `yaml:"oversize" toml:"oversize"`
+	Order              *string `yaml:"order" toml:"order"`
+	TreeAnnotate       *bool   `yaml:"tree_annotate" toml:"tree_annotate"`
+	TreeOnly           *bool   `yaml:"tree_only" toml:"tree_only"`
+	TreeFiltered       *bool   `yaml:"tree_filtered" toml:"tree_filtered"`
+	Jobs               *int    `yaml:"jobs" toml:"jobs"`
+	ReadTimeout        *string `yaml:"read_timeout" toml:"read_timeout"`
+	Strict             *bool   `yaml:"strict" toml:"strict"`
+}
+
+// File is a config file: top-level settings plus named profiles that are
+// applied on top of them.
+type File struct {
+	Settings `yaml:",inline"`
+	Profiles map[string]Settings `yaml:"profiles" toml:"profiles"`
+}
+
+// FileNames are the names of a project config file, searched for from the
+// directory given to Load up to the repository root.
+var FileNames = []string{".gogpt.yaml", ".gogpt.yml", ".gogpt.toml"}
+
+// userFileNames are the names of the user config file in UserDir.
+var userFileNames = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Load merges the user config with the project config found from dir, then
+// applies the named profile, if any. Later sources override earlier ones:
+// user settings, project settings, the user profile and the project profile.
+// It returns the merged settings and the paths of the files that were read.
+func Load(dir, profile string) (Settings, []string, error) {
+	var files []*File
+	var paths []string
+
+	for _, find := range []func() (string, error){
+		func() (string, error) { return findIn(UserDir(), userFileNames) },
+		func() (string, error) { return findProject(dir) },
+	} {
+		path, err := find()
+		if err != nil {
+			return Settings{}, nil, err
+		}
+		if path == "" {
+			continue
+		}
+
+		file, err := ReadFile(path)
+		if err != nil {
+			return Settings{}, nil, err
+		}
+		files = append(files, file)
+		paths = append(paths, path)
+	}
+
+	var settings Settings
+	for _, file := range files {
+		settings.Merge(file.Settings)
+	}
+
+	if profile == "" {
+		return settings, paths, nil
+	}
+
+	found := false
+	for _, file := range files {
+		if p, ok := file.Profiles[profile]; ok {
+			settings.Merge(p)
+			found = true
+		}
+	}
+	if !found {
+		return Settings{}, nil, fmt.Errorf("profile %q is not defined in any config file", profile)
+	}
+
+	return settings, paths, nil
+}
+
+// ReadFile parses a YAML or TOML config file, chosen by its extension.
+// Unknown keys are rejected so typos do not go unnoticed.
+func ReadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file File
+	if filepath.Ext(path) == ".toml" {
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&file)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&file)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return &file, nil
+}
+
+// Merge sets every option that is set in other.
+func (s *Settings) Merge(other Settings) {
+	mergeValue(&s.Output, other.Output)
+	mergeValue(&s.GitIgnore, other.GitIgnore)
+	mergeList(&s.Languages, other.Languages)
+	mergeValue(&s.MaxTokens, other.MaxTokens)
+	mergeValue(&s.Verbose, other.Verbose)
+	mergeValue(&s.Exclude, other.Exclude)
+	mergeList(&s.ExcludePaths, other.ExcludePaths)
+	mergeList(&s.IncludePaths, other.IncludePaths)
+	mergeValue(&s.SubstringExclude, other.SubstringExclude)
+	mergeValue(&s.IncludeGenerated, other.IncludeGenerated)
+	mergeValue(&s.IncludeVendored, other.IncludeVendored)
+	mergeValue(&s.StripComments, other.StripComments)
+	mergeValue(&s.CollapseBlankLines, other.CollapseBlankLines)
+	mergeValue(&s.KeepDocComments, other.KeepDocComments)
+	mergeValue(&s.Signatures, other.Signatures)
+	mergeValue(&s.Oversize, other.Oversize)
+	mergeValue(&s.Order, other.Order)
+	mergeValue(&s.TreeAnnotate, other.TreeAnnotate)
+	mergeValue(&s.TreeOnly, other.TreeOnly)
+	mergeValue(&s.TreeFiltered, other.TreeFiltered)
+	mergeValue(&s.Jobs, other.Jobs)
+	mergeValue(&s.ReadTimeout, other.ReadTimeout)
+	mergeValue(&s.Strict, other.Strict)
+	mergeValue(&s.Encoding, other.Encoding)
+	mergeValue(&s.Budget, other.Budget)
+	mergeValue(&s.Pack, other.Pack)
+	mergeList(&s.Priority, other.Priority)
+	mergeValue(&s.ChunkTokens, other.ChunkTokens)
+	mergeValue(&s.Format, other.Format)
+	mergeValue(&s.Since, other.Since)
+	mergeValue(&s.Staged, other.Staged)
+	mergeValue(&s.Commit, other.Commit)
+	mergeValue(&s.Diff, other.Diff)
+	mergeValue(&s.Rev, other.Rev)
+}
+
+func mergeValue[T any](dst **T, src *T) {
+	if src != nil {
+		*dst = src
+	}
+}
+
+func mergeList(dst *[]string, src []string) {
+	if src != nil {
+		*dst = src
+	}
+}
+
+// UserDir is the directory of the user config file:
+// $XDG_CONFIG_HOME/gogpt, or ~/.config/gogpt when it is not set.
+func UserDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gogpt")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gogpt")
+}
+
+// findProject looks for a project config file in dir and its parents,
+// stopping at the repository root (the directory containing .git).
+func findProject(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	for {
+		path, err := findIn(dir, FileNames)
+		if path != "" || err != nil {
+			return path, err
+		}
+
+		parent := filepath.Dir(dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// findIn returns the config file in dir, or "" if there is none. Having more
+// than one is an error rather than a silent choice.
+func findIn(dir string, names []string) (string, error) {
+	if dir == "" {
+		return "", nil
+	}
+
+	var found []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			found = append(found, path)
+		}
+	}
+
+	if len(found) > 1 {
+		return "", fmt.Errorf("found more than one config file: %s and %s", found[0], found[1])
+	}
+	if len(found) == 0 {
+		return "", nil
+	}
+	return found[0], nil
+}
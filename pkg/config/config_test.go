@@ -0,0 +1,142 @@
+// File: pkg/config/config_test.go
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func writeFile(t *testing.T, path, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestLoad(t *testing.T) {
+	userDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", userDir)
+	writeFile(t, filepath.Join(userDir, "gogpt", "config.yaml"), `format: xml
+max_tokens: 1000
+profiles:
+  docs:
+    languages: [md]
+    budget: 50000
+`)
+
+	repoDir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(repoDir, ".git"), 0755))
+	writeFile(t, filepath.Join(repoDir, ".gogpt.toml"), `max_tokens = 2000
+exclude_paths = ["vendor"]
+
+[profiles.docs]
+budget = 80000
+
+[profiles.review]
+since = "main"
+`)
+	subDir := filepath.Join(repoDir, "cmd", "app")
+	require.NoError(t, os.MkdirAll(subDir, 0755))
+
+	tests := []struct {
+		name     string
+		profile  string
+		expected Settings
+		wantErr  bool
+	}{
+		{
+			name:    "Project settings override user settings",
+			profile: "",
+			expected: Settings{
+				Format:       strPtr("xml"),
+				MaxTokens:    intPtr(2000),
+				ExcludePaths: []string{"vendor"},
+			},
+		},
+		{
+			name:    "Project profile overrides user profile",
+			profile: "docs",
+			expected: Settings{
+				Format:       strPtr("xml"),
+				MaxTokens:    intPtr(2000),
+				ExcludePaths: []string{"vendor"},
+				Languages:    []string{"md"},
+				Budget:       intPtr(80000),
+			},
+		},
+		{
+			name:    "Profile from one file",
+			profile: "review",
+			expected: Settings{
+				Format:       strPtr("xml"),
+				MaxTokens:    intPtr(2000),
+				ExcludePaths: []string{"vendor"},
+				Since:        strPtr("main"),
+			},
+		},
+		{
+			name:    "Unknown profile",
+			profile: "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings, paths, err := Load(subDir, tt.profile)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, settings)
+			assert.Len(t, paths, 2)
+		})
+	}
+}
+
+func TestLoadStopsAtRepositoryRoot(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	parentDir := t.TempDir()
+	writeFile(t, filepath.Join(parentDir, ".gogpt.yaml"), "max_tokens: 10\n")
+	repoDir := filepath.Join(parentDir, "repo")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, ".git"), 0755))
+
+	settings, paths, err := Load(repoDir, "")
+	require.NoError(t, err)
+	assert.Empty(t, paths)
+	assert.Equal(t, Settings{}, settings)
+}
+
+func TestReadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	unknownKey := filepath.Join(dir, "unknown.yaml")
+	writeFile(t, unknownKey, "max_token: 10\n")
+	_, err := ReadFile(unknownKey)
+	assert.Error(t, err)
+
+	empty := filepath.Join(dir, "empty.yaml")
+	writeFile(t, empty, "")
+	file, err := ReadFile(empty)
+	require.NoError(t, err)
+	assert.Equal(t, &File{}, file)
+
+	both := t.TempDir()
+	writeFile(t, filepath.Join(both, ".gogpt.yaml"), "")
+	writeFile(t, filepath.Join(both, ".gogpt.toml"), "")
+	_, err = findIn(both, FileNames)
+	assert.Error(t, err)
+}
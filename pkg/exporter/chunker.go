@@ -0,0 +1,97 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/tiktoken"
+)
+
+type Chunk struct {
+	Files  []FileInfo
+	Tokens int
+}
+
+// Chunker distributes files over numbered parts of a bounded token size.
+type Chunker struct {
+	encoding *tiktoken.Encoding
+	format   Format
+}
+
+func NewChunker(encoding *tiktoken.Encoding, format Format) *Chunker {
+	return &Chunker{encoding: encoding, format: format}
+}
+
+// Split fills chunks of at most limit tokens in file order. A file is only
+// split across chunks, at line boundaries, when it does not fit in one alone.
+func (c *Chunker) Split(files []FileInfo, limit int) []Chunk {
+	var chunks []Chunk
+	var current Chunk
+
+	add := func(file FileInfo) {
+		cost := fileCost(c.encoding, c.format, file)
+		if len(current.Files) > 0 && current.Tokens+cost > limit {
+			chunks = append(chunks, current)
+			current = Chunk{}
+		}
+		current.Files = append(current.Files, file)
+		current.Tokens += cost
+	}
+
+	for _, file := range files {
+		if file.Excluded || fileCost(c.encoding, c.format, file) <= limit {
+			add(file)
+			continue
+		}
+		for _, segment := range c.segments(file, limit) {
+			add(segment)
+		}
+	}
+
+	if len(current.Files) > 0 {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// segments splits a file into runs of whole lines that each fit within limit.
+// A single line longer than the limit still becomes its own segment. Segments
+// keep the rest of the file's info, with its diff after the last one.
+func (c *Chunker) segments(file FileInfo, limit int) []FileInfo {
+	lines := strings.SplitAfter(string(file.Content), "\n")
+	if len(lines) > 1 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	placeholder := fmt.Sprintf("lines %d-%d of %d", len(lines), len(lines), len(lines))
+	wrapper := file
+	wrapper.Content, wrapper.TokenCount, wrapper.Segment = nil, 0, placeholder
+	available := limit - fileCost(c.encoding, c.format, wrapper)
+
+	var segments []FileInfo
+	start, tokens := 0, 0
+
+	flush := func(end int) {
+		content := strings.Join(lines[start:end], "")
+		segment := file
+		segment.Content = []byte(content)
+		segment.TokenCount = c.encoding.Count(content)
+		segment.Segment = fmt.Sprintf("lines %d-%d of %d", start+1, end, len(lines))
+		segment.Diff = nil
+		segments = append(segments, segment)
+		start, tokens = end, 0
+	}
+
+	for i, line := range lines {
+		lineTokens := c.encoding.Count(line)
+		if i > start && tokens+lineTokens > available {
+			flush(i)
+		}
+		tokens += lineTokens
+	}
+	flush(len(lines))
+
+	segments[len(segments)-1].Diff = file.Diff
+	return segments
+}
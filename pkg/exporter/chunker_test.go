@@ -0,0 +1,74 @@
+// File: pkg/exporter/chunker_test.go
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daemonp/gogpt/pkg/tiktoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkerSplit(t *testing.T) {
+	encoding, err := tiktoken.GetEncoding(tiktoken.CL100kBase)
+	require.NoError(t, err)
+
+	file := func(path, content string) FileInfo {
+		return FileInfo{Path: path, Content: []byte(content), TokenCount: encoding.Count(content)}
+	}
+
+	bigContent := strings.Repeat("line of text\n", 100)
+	big := file("big.txt", bigContent)
+	big.Language = "text"
+	big.Status = "modified"
+	files := []FileInfo{
+		file("a.go", "package a\n"),
+		file("b.go", "package b\n"),
+		big,
+		file("c.go", "package c\n"),
+	}
+
+	chunker := NewChunker(encoding, &MarkdownFormat{})
+	chunks := chunker.Split(files, 120)
+
+	var rebuilt strings.Builder
+	var names []string
+	for _, chunk := range chunks {
+		assert.LessOrEqual(t, chunk.Tokens, 120)
+		for _, f := range chunk.Files {
+			names = append(names, f.Path)
+			if f.Path == "big.txt" {
+				assert.NotEmpty(t, f.Segment)
+				assert.Equal(t, "text", f.Language)
+				assert.Equal(t, "modified", f.Status)
+				rebuilt.Write(f.Content)
+			}
+		}
+	}
+
+	assert.Equal(t, "a.go", names[0])
+	assert.Equal(t, "b.go", names[1])
+	assert.Equal(t, "c.go", names[len(names)-1])
+	assert.Equal(t, bigContent, rebuilt.String())
+	assert.Greater(t, len(chunks), 2)
+}
+
+func TestChunkFileName(t *testing.T) {
+	tests := []struct {
+		outputFile string
+		part       int
+		expected   string
+	}{
+		{"", 1, "output.part01.md"},
+		{"export.txt", 2, "export.part02.txt"},
+		{"out/export", 12, "out/export.part12.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			assert.Equal(t, tt.expected, chunkFileName(tt.outputFile, ".md", tt.part))
+		})
+	}
+}
@@ -3,48 +3,133 @@
 package exporter
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
-	"github.com/daemonp/gogpt/pkg/gitignore"
+	"github.com/daemonp/gogpt/pkg/classifier"
+	"github.com/daemonp/gogpt/pkg/git"
 	"github.com/daemonp/gogpt/pkg/languagedetector"
+	"github.com/daemonp/gogpt/pkg/tiktoken"
 	"github.com/daemonp/gogpt/pkg/types"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+const exportTitle = "Repository Export"
+
 type Exporter struct {
-	rootDir       string
+	roots         []*root
 	flags         *types.Flags
-	fileProcessor *FileProcessor
 	contentFilter *ContentFilter
 	treeGenerator *TreeGenerator
+	packer        *Packer
+	chunker       *Chunker
+	format        Format
+	encoding      *tiktoken.Encoding
 	writer        *Writer
+	// output receives the export unless it is written to files.
+	output io.Writer
+	// languageStats is set when the languages were detected rather than
+	// given, to report their shares in the header.
+	languageStats languagedetector.Stats
+	// skipped lists the files the classifier left out, across all roots.
+	skipped []SkippedFile
+	// failed lists the files that could not be read or processed, across
+	// all roots.
+	failed []FileError
+	// tokensSaved is the number of tokens removed by content reductions.
+	tokensSaved int
+	// spool holds the contents of the files between scanning and writing,
+	// spilling to a temporary file in spoolDir.
+	spool    *spool
+	spoolDir string
+	logger   zerolog.Logger
 }
 
-func New(rootDir string, flags *types.Flags) (*Exporter, error) {
-	absRootDir, err := filepath.Abs(rootDir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+// ScanError reports the files that could not be read or processed. Export
+// returns it once the export is written without them or, with --strict,
+// instead of writing the export.
+type ScanError struct {
+	Failed []FileError
+	// Written is whether the export was written without the failed files.
+	Written bool
+}
+
+func (e *ScanError) Error() string {
+	failed := make([]string, len(e.Failed))
+	for i, f := range e.Failed {
+		failed[i] = f.Error()
 	}
+	return fmt.Sprintf("%d files could not be exported: %s", len(e.Failed), strings.Join(failed, "; "))
+}
+
+func New(ctx context.Context, rootDir string, flags *types.Flags) (*Exporter, error) {
+	return NewWithRoots(ctx, []string{rootDir}, flags)
+}
 
-	if _, err := os.Stat(absRootDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("directory does not exist: %s", absRootDir)
+// NewWithRoots creates an exporter for one or more directories. With more
+// than one, each root gets its own tree and its file paths are prefixed with
+// the root's label. ctx bounds the language detection.
+func NewWithRoots(ctx context.Context, rootDirs []string, flags *types.Flags) (*Exporter, error) {
+	if len(rootDirs) == 0 {
+		return nil, fmt.Errorf("no directories to export")
 	}
 
-	var gitIgnore *gitignore.GitIgnore
-	if flags.UseGitIgnore {
-		gitIgnore, err = gitignore.NewGitIgnore(absRootDir)
+	changeSet, hasChangeSet, err := changeSetFromFlags(flags)
+	if err != nil {
+		return nil, err
+	}
+	if flags.Rev != "" && hasChangeSet {
+		return nil, fmt.Errorf("--rev cannot be combined with --since, --staged or --commit")
+	}
+
+	roots := make([]*root, 0, len(rootDirs))
+	for _, dir := range rootDirs {
+		r, err := openRoot(dir, flags, flags.Rev != "" || hasChangeSet)
 		if err != nil {
-			log.Warn().Err(err).Msg("Failed to parse .gitignore files, continuing without gitignore")
+			closeRoots(roots)
+			return nil, err
+		}
+		roots = append(roots, r)
+	}
+	labelRoots(roots)
+
+	e, err := newExporter(ctx, roots, flags, os.Stdout, log.Logger)
+	if err != nil {
+		closeRoots(roots)
+		return nil, err
+	}
+
+	if hasChangeSet {
+		for _, r := range roots {
+			r.fileProcessor.SetChangeSet(r.repo, changeSet, flags.IncludeDiff)
 		}
 	}
+	return e, nil
+}
+
+// newExporter creates an exporter for opened roots, writing to output. The
+// flags are copied, as the detected languages are filled in.
+func newExporter(ctx context.Context, roots []*root, flags *types.Flags, output io.Writer, logger zerolog.Logger) (*Exporter, error) {
+	copied := *flags
+	flags = &copied
 
 	// If no languages are specified, detect them automatically
+	var languageStats languagedetector.Stats
 	if flags.Languages == "" {
-		detectedLangs := languagedetector.DetectLanguages(absRootDir)
-		flags.Languages = detectedLangs
-		log.Info().Str("languages", flags.Languages).Msg("Detected languages")
+		var err error
+		languageStats, err = detectLanguages(ctx, roots, flags)
+		if err != nil {
+			return nil, err
+		}
+		flags.Languages = strings.Join(languageStats.Names(), ",")
+		logger.Info().Str("languages", flags.Languages).Msg("Detected languages")
 	}
 
 	contentFilter, err := NewContentFilter(flags.ExcludePattern)
@@ -52,87 +137,532 @@ func New(rootDir string, flags *types.Flags) (*Exporter, error) {
 		return nil, fmt.Errorf("failed to create content filter: %w", err)
 	}
 
-	fileProcessor := NewFileProcessor(absRootDir, flags, gitIgnore)
-	treeGenerator := NewTreeGenerator()
-	writer := NewWriter(os.Stdout)
+	encoding, err := tiktoken.GetEncoding(flags.Encoding)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tokenizer: %w", err)
+	}
+
+	format, err := NewFormat(flags.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateOrder(flags.Order); err != nil {
+		return nil, err
+	}
+
+	var packer *Packer
+	if flags.Budget != nil {
+		packer, err = NewPacker(flags.PackStrategy, flags.PriorityGlobs, encoding, format)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create packer: %w", err)
+		}
+	}
+
+	for _, r := range roots {
+		r.fileProcessor, err = NewFileProcessor(r.dir, flags, r.gitIgnore, encoding)
+		if err != nil {
+			return nil, err
+		}
+		r.fileProcessor.SetFS(r.fsys)
+		r.fileProcessor.SetLogger(logger)
+	}
 
 	return &Exporter{
-		rootDir:       absRootDir,
+		roots:         roots,
 		flags:         flags,
-		fileProcessor: fileProcessor,
 		contentFilter: contentFilter,
-		treeGenerator: treeGenerator,
-		writer:        writer,
+		treeGenerator: NewTreeGenerator(flags.TreeAnnotate),
+		packer:        packer,
+		chunker:       NewChunker(encoding, format),
+		format:        format,
+		encoding:      encoding,
+		writer:        NewWriter(output, format),
+		output:        output,
+		languageStats: languageStats,
+		logger:        logger,
 	}, nil
 }
 
-func (e *Exporter) Export() error {
-	files, err := e.fileProcessor.ScanFiles()
-	if err != nil {
-		return fmt.Errorf("failed to scan files: %w", err)
-	}
+// Export writes the export. When ctx is cancelled, it stops at the next file
+// and returns ctx's error, removing a partly written output file.
+func (e *Exporter) Export(ctx context.Context) error {
+	defer closeRoots(e.roots)
+
+	e.spool = newSpool(e.spoolDir, e.logger)
+	defer e.spool.close()
 
-	treeStructure, err := e.treeGenerator.Generate(files)
+	files, trees, err := e.scanRoots(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to generate tree structure: %w", err)
+		return err
+	}
+	if e.flags.Strict && len(e.failed) > 0 {
+		return &ScanError{Failed: e.failed}
 	}
 
-	if e.flags.OutputFile != "" {
-		file, err := os.Create(e.flags.OutputFile)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
+	if e.flags.TreeOnly {
+		if err := e.exportTrees(ctx, trees); err != nil {
+			return err
 		}
-		defer file.Close()
-		e.writer = NewWriter(file)
+		return e.scanError()
 	}
 
-	e.writer.Write("# Repository Export\n\n")
-	e.writer.Write("This document is a structured representation of the contents of the repository. It includes a list of files and their contents as per the following criteria:\n\n")
-	e.writer.Write(fmt.Sprintf("* Files are included based on the specified languages: %s.\n", e.flags.Languages))
-	e.writer.Write("* Files ignored by .gitignore are excluded.\n")
-	if e.flags.MaxTokens != nil {
-		e.writer.Write(fmt.Sprintf("* Files exceeding the token limit (%d tokens) are noted but not included.\n", *e.flags.MaxTokens))
+	var dropped []DroppedFile
+	if e.packer != nil {
+		files, dropped = e.pack(files, trees)
 	}
-	e.writer.Write(fmt.Sprintf("* Lines matching the exclude pattern '%s' are filtered out.\n", e.flags.ExcludePattern))
-	e.writer.Write("\n")
-
-	e.writer.Write(treeStructure)
 
 	var totalSize int64
 	var totalTokens int
 
 	for _, file := range files {
-		if !file.Excluded {
-			file.Content = e.contentFilter.Filter(file.Content)
-		}
-
-		fileSize := int64(len(file.Content))
+		fileSize := int64(file.contentSize())
 		totalSize += fileSize
 		totalTokens += file.TokenCount
 
 		if e.flags.Verbose {
-			logFileInfo(file.Path, fileSize, file.TokenCount)
+			e.logFileInfo(file.Path, fileSize, file.TokenCount, file.TokensSaved)
 		}
 	}
 
-	if err := e.writer.WriteFileContents(files); err != nil {
-		return fmt.Errorf("failed to write file contents: %w", err)
+	if e.flags.ChunkTokens != nil {
+		err = e.exportChunks(ctx, files, trees, dropped)
+	} else {
+		err = e.exportSingle(ctx, files, trees, dropped)
+	}
+	if err != nil {
+		return err
 	}
 
 	// Log summary
-	log.Info().
+	e.logger.Info().
 		Float64("total_size_kb", float64(totalSize)/1024.0).
 		Int("total_tokens", totalTokens).
 		Msg("Export completed")
 
+	return e.scanError()
+}
+
+// scanError returns the ScanError for a written export, or nil if every
+// file was read.
+func (e *Exporter) scanError() error {
+	if len(e.failed) == 0 {
+		return nil
+	}
+	return &ScanError{Failed: e.failed, Written: true}
+}
+
+// scanRoots scans every root, orders its files and generates its tree in
+// the same order. With more than one root, file paths are
+// prefixed with the root's label after the tree is built.
+func (e *Exporter) scanRoots(ctx context.Context) ([]FileInfo, []Tree, error) {
+	var files []FileInfo
+	var trees []Tree
+
+	for _, r := range e.roots {
+		rootFiles, err := e.scanRoot(ctx, r)
+		if err != nil {
+			return nil, nil, err
+		}
+		orderFiles(rootFiles, e.flags.Order, e.spool.content, func(path string) ([]byte, error) {
+			return r.fileProcessor.readFile(ctx, path)
+		})
+
+		var hidden []SkippedFile
+		if e.flags.TreeFiltered {
+			hidden = append(r.fileProcessor.Filtered(), r.fileProcessor.Skipped()...)
+		}
+		structure, err := e.treeGenerator.Generate(r.label, rootFiles, hidden)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate tree structure: %w", err)
+		}
+
+		tree := Tree{Structure: structure}
+		if len(e.roots) > 1 {
+			tree.Label = r.label
+			for i := range rootFiles {
+				rootFiles[i].Path = filepath.Join(r.label, rootFiles[i].Path)
+			}
+		}
+
+		for _, skipped := range r.fileProcessor.Skipped() {
+			if len(e.roots) > 1 {
+				skipped.Path = filepath.Join(r.label, skipped.Path)
+			}
+			e.skipped = append(e.skipped, skipped)
+		}
+		for _, failed := range r.fileProcessor.Failed() {
+			if len(e.roots) > 1 {
+				failed.Path = filepath.Join(r.label, failed.Path)
+			}
+			e.failed = append(e.failed, failed)
+		}
+
+		trees = append(trees, tree)
+		files = append(files, rootFiles...)
+	}
+
+	return files, trees, nil
+}
+
+// exportTrees writes only the directory structure of every root, in the
+// output format.
+func (e *Exporter) exportTrees(ctx context.Context, trees []Tree) (err error) {
+	output := e.output
+	if e.flags.OutputFile != "" {
+		var file *os.File
+		if file, err = os.Create(e.flags.OutputFile); err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutput(file, &err)
+		output = file
+	}
+
+	if err := ctx.Err(); err != nil {
+		return e.abandon(e.flags.OutputFile, err)
+	}
+	if err := e.format.WriteTrees(output, trees); err != nil {
+		return fmt.Errorf("failed to write tree structure: %w", err)
+	}
+	return nil
+}
+
+// closeOutput closes an output file, reporting a failure to write out what
+// is buffered as the export's error unless it already failed.
+func closeOutput(file *os.File, err *error) {
+	if closeErr := file.Close(); closeErr != nil && *err == nil {
+		*err = fmt.Errorf("failed to close output file: %w", closeErr)
+	}
+}
+
+// abandon removes a partly written output file when the export is
+// cancelled, and returns err.
+func (e *Exporter) abandon(path string, err error) error {
+	if path != "" && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+		if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+			e.logger.Warn().Err(removeErr).Str("file", path).Msg("Failed to remove partial output file")
+		}
+	}
+	return err
+}
+
+func (e *Exporter) exportSingle(ctx context.Context, files []FileInfo, trees []Tree, dropped []DroppedFile) (err error) {
+	if e.flags.OutputFile != "" {
+		var file *os.File
+		if file, err = os.Create(e.flags.OutputFile); err != nil {
+			return fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer closeOutput(file, &err)
+		e.writer = NewWriter(file, e.format)
+	}
+
+	if err := e.writer.WriteHeader(e.header(exportTitle, dropped, trees)); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	if err := e.writeFiles(ctx, e.writer, files); err != nil {
+		if ctx.Err() != nil {
+			return e.abandon(e.flags.OutputFile, err)
+		}
+		return fmt.Errorf("failed to write file contents: %w", err)
+	}
+
+	if err := e.writer.WriteFooter(summarize(files)); err != nil {
+		return fmt.Errorf("failed to write footer: %w", err)
+	}
+
 	return nil
 }
 
-func logFileInfo(path string, sizeInBytes int64, tokenCount int) {
+// exportChunks writes the files to numbered parts that each fit the chunk
+// size. Every part repeats the header and tree and ends with a manifest of all
+// parts, and since the manifest grows with the number of parts the split is
+// retried until every part fits alongside it.
+func (e *Exporter) exportChunks(ctx context.Context, files []FileInfo, trees []Tree, dropped []DroppedFile) error {
+	limit := *e.flags.ChunkTokens
+	fixed := e.encoding.Count(e.renderHeader(e.header(partTitle(len(files), len(files)), dropped, trees)))
+	reserve := e.encoding.Count(e.renderFooter(files, []Chunk{{Files: files}}, 0))
+
+	var chunks []Chunk
+	for {
+		available := limit - fixed - reserve
+		if available <= 0 {
+			return fmt.Errorf("chunk size of %d tokens leaves no room for files after the header, tree and manifest (%d tokens)", limit, fixed+reserve)
+		}
+
+		if err := e.loadLarge(files, available); err != nil {
+			return err
+		}
+		chunks = e.chunker.Split(files, available)
+
+		needed := 0
+		for i, chunk := range chunks {
+			needed = max(needed, e.encoding.Count(e.renderFooter(chunk.Files, chunks, i)))
+		}
+		if needed <= reserve {
+			break
+		}
+		reserve = needed
+	}
+
+	for i, chunk := range chunks {
+		path := chunkFileName(e.flags.OutputFile, e.format.Extension(), i+1)
+		if err := e.writeChunk(ctx, path, chunks, i, trees, dropped); err != nil {
+			return err
+		}
+		e.logger.Info().Str("file", path).Int("files", len(chunk.Files)).Int("tokens", chunk.Tokens).Msg("Wrote export part")
+	}
+
+	return nil
+}
+
+func (e *Exporter) writeChunk(ctx context.Context, path string, chunks []Chunk, index int, trees []Tree, dropped []DroppedFile) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer closeOutput(file, &err)
+
+	writer := NewWriter(file, e.format)
+	if err := writer.WriteHeader(e.header(partTitle(index+1, len(chunks)), dropped, trees)); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	if err := e.writeFiles(ctx, writer, chunks[index].Files); err != nil {
+		if ctx.Err() != nil {
+			return e.abandon(path, err)
+		}
+		return fmt.Errorf("failed to write file contents: %w", err)
+	}
+
+	summary := summarize(chunks[index].Files)
+	summary.Chunks = chunks
+	summary.Part = index
+	if err := writer.WriteFooter(summary); err != nil {
+		return fmt.Errorf("failed to write footer: %w", err)
+	}
+
+	return nil
+}
+
+// chunkFileName numbers an output path, turning "export.md" into
+// "export.part01.md". Without an output path the parts are named
+// output.partNN with the format's extension.
+func chunkFileName(outputFile, defaultExt string, part int) string {
+	if outputFile == "" {
+		outputFile = "output" + defaultExt
+	}
+	ext := filepath.Ext(outputFile)
+	if ext == "" {
+		ext = defaultExt
+	}
+	return fmt.Sprintf("%s.part%02d%s", strings.TrimSuffix(outputFile, filepath.Ext(outputFile)), part, ext)
+}
+
+func partTitle(part, total int) string {
+	return fmt.Sprintf("%s (Part %d of %d)", exportTitle, part, total)
+}
+
+func (e *Exporter) header(title string, dropped []DroppedFile, trees []Tree) Header {
+	languages := fmt.Sprintf("Files are included based on the specified languages: %s.", e.flags.Languages)
+	if len(e.languageStats) > 0 {
+		shares := make([]string, len(e.languageStats))
+		for i, stats := range e.languageStats {
+			shares[i] = fmt.Sprintf("%s (%.1f%%)", stats.Language, stats.Percentage)
+		}
+		languages = fmt.Sprintf("Files are included based on the detected languages, by share of the repository: %s.", strings.Join(shares, ", "))
+	}
+	criteria := []string{
+		languages,
+		"Files ignored by .gitignore are excluded.",
+	}
+	if e.flags.MaxTokens != nil {
+		criteria = append(criteria, oversizeCriterion(e.flags.Oversize, *e.flags.MaxTokens))
+	}
+	criteria = append(criteria, fmt.Sprintf("Lines matching the exclude pattern '%s' are filtered out.", e.flags.ExcludePattern))
+	if len(e.roots) > 1 {
+		criteria = append(criteria, fmt.Sprintf("Files from %d directories are included, with paths prefixed by their root: %s.", len(e.roots), strings.Join(e.rootLabels(), ", ")))
+	}
+	if e.flags.Rev != "" {
+		criteria = append(criteria, fmt.Sprintf("Files are read from git revision %s (%s) instead of the working tree.", e.flags.Rev, e.revisionCommits()))
+	}
+	if changeSet, ok, _ := changeSetFromFlags(e.flags); ok {
+		criteria = append(criteria, fmt.Sprintf("Only files in the %s are included, marked with their git status.", changeSet))
+		if e.flags.IncludeDiff {
+			criteria = append(criteria, "Each changed file is followed by its unified diff.")
+		}
+	}
+	if order := orderCriterion(e.flags.Order); order != "" {
+		criteria = append(criteria, order)
+	}
+	criteria = append(criteria, e.skippedCriterion())
+	if len(e.failed) > 0 {
+		criteria = append(criteria, e.failedCriterion())
+	}
+	criteria = append(criteria, e.reductionCriteria()...)
+	if e.flags.Budget != nil {
+		if len(dropped) == 0 {
+			criteria = append(criteria, fmt.Sprintf("The export is limited to a budget of %d tokens; all files fit.", *e.flags.Budget))
+		} else {
+			criteria = append(criteria, fmt.Sprintf("The export is limited to a budget of %d tokens; %d files were left out:", *e.flags.Budget, len(dropped)))
+		}
+	}
+
+	return Header{
+		Title:    title,
+		Criteria: criteria,
+		Dropped:  dropped,
+		Trees:    trees,
+	}
+}
+
+// skippedCriterion names the kinds of files the classifier skips and lists
+// the files and vendored directories skipped by the scan.
+func (e *Exporter) skippedCriterion() string {
+	kinds := []string{classifier.Binary}
+	if !e.flags.IncludeGenerated {
+		kinds = append(kinds, classifier.Minified, classifier.Generated)
+	}
+	if !e.flags.IncludeVendored {
+		kinds = append(kinds, classifier.Vendored)
+	}
+	names := kinds[len(kinds)-1]
+	if len(kinds) > 1 {
+		names = strings.Join(kinds[:len(kinds)-1], ", ") + " and " + names
+	}
+	criterion := strings.ToUpper(names[:1]) + names[1:] + " files are skipped"
+
+	if len(e.skipped) == 0 {
+		return criterion + "."
+	}
+	skipped := make([]string, len(e.skipped))
+	for i, file := range e.skipped {
+		path := filepath.ToSlash(file.Path)
+		if file.Reason == classifier.Vendored {
+			path += "/"
+		}
+		skipped[i] = fmt.Sprintf("%s (%s)", path, file.Reason)
+	}
+	return fmt.Sprintf("%s: %s.", criterion, strings.Join(skipped, ", "))
+}
+
+// failedCriterion lists the files that could not be read or processed.
+func (e *Exporter) failedCriterion() string {
+	failed := make([]string, len(e.failed))
+	for i, file := range e.failed {
+		failed[i] = fmt.Sprintf("%s (%v)", filepath.ToSlash(file.Path), file.Err)
+	}
+	return fmt.Sprintf("Files that could not be read are left out: %s.", strings.Join(failed, ", "))
+}
+
+func (e *Exporter) renderHeader(header Header) string {
+	var buf bytes.Buffer
+	_ = e.format.WriteHeader(&buf, header)
+	return buf.String()
+}
+
+func (e *Exporter) renderFooter(files []FileInfo, chunks []Chunk, part int) string {
+	summary := summarize(files)
+	summary.Chunks = chunks
+	summary.Part = part
+
+	var buf bytes.Buffer
+	_ = e.format.WriteFooter(&buf, summary)
+	return buf.String()
+}
+
+func summarize(files []FileInfo) Summary {
+	summary := Summary{Files: len(files)}
+	for _, file := range files {
+		summary.TotalSize += int64(file.contentSize())
+		summary.TotalTokens += file.TokenCount
+	}
+	return summary
+}
+
+// pack fits the files into the budget left after the header and tree. The
+// list of dropped files is itself part of the header, so the budget shrinks
+// until the files fit alongside it.
+func (e *Exporter) pack(files []FileInfo, trees []Tree) ([]FileInfo, []DroppedFile) {
+	baseCost := e.encoding.Count(e.renderHeader(e.header(exportTitle, nil, trees)))
+	budget := *e.flags.Budget - baseCost
+
+	for {
+		kept, dropped := e.packer.Pack(files, budget)
+
+		overhead := e.encoding.Count(e.renderHeader(e.header(exportTitle, dropped, trees))) - baseCost
+		available := *e.flags.Budget - baseCost - overhead
+		if e.packer.totalCost(kept) <= available || len(kept) == 0 {
+			for _, d := range dropped {
+				e.logger.Warn().Str("file", d.Path).Int("tokens", d.TokenCount).Str("reason", d.Reason).Msg("File dropped to fit the budget")
+			}
+			return kept, dropped
+		}
+
+		budget = min(budget-1, available)
+	}
+}
+
+// changeSetFromFlags returns the git change set selected by --since, --staged
+// or --commit, and whether one was selected.
+func changeSetFromFlags(flags *types.Flags) (git.ChangeSet, bool, error) {
+	changeSet := git.ChangeSet{Since: flags.Since, Staged: flags.Staged, Commit: flags.Commit}
+
+	selected := 0
+	for _, set := range []bool{flags.Since != "", flags.Staged, flags.Commit != ""} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return changeSet, false, fmt.Errorf("only one of --since, --staged and --commit can be used")
+	}
+	return changeSet, selected == 1, nil
+}
+
+func (e *Exporter) rootLabels() []string {
+	labels := make([]string, len(e.roots))
+	for i, r := range e.roots {
+		labels[i] = r.label
+	}
+	return labels
+}
+
+// revisionCommits names the commit --rev resolved to, per root when there is
+// more than one.
+func (e *Exporter) revisionCommits() string {
+	if len(e.roots) == 1 {
+		return "commit " + shortCommit(e.roots[0].commit)
+	}
+
+	commits := make([]string, len(e.roots))
+	for i, r := range e.roots {
+		commits[i] = fmt.Sprintf("%s at commit %s", r.label, shortCommit(r.commit))
+	}
+	return strings.Join(commits, ", ")
+}
+
+func closeRoots(roots []*root) {
+	for _, r := range roots {
+		r.close()
+	}
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
+
+func (e *Exporter) logFileInfo(path string, sizeInBytes int64, tokenCount, tokensSaved int) {
 	sizeInKB := float64(sizeInBytes) / 1024.0
-	log.Debug().
+	event := e.logger.Debug().
 		Str("file", path).
 		Float64("size_kb", sizeInKB).
-		Int("tokens", tokenCount).
-		Msg("File processed")
+		Int("tokens", tokenCount)
+	if tokensSaved > 0 {
+		event = event.Int("tokens_saved", tokensSaved)
+	}
+	event.Msg("File processed")
 }
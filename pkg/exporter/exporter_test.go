@@ -3,11 +3,19 @@
 package exporter
 
 import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/daemonp/gogpt/pkg/tiktoken"
 	"github.com/daemonp/gogpt/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,7 +53,7 @@ func TestNew(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			exp, err := New(tt.dir, tt.flags)
+			exp, err := New(context.Background(), tt.dir, tt.flags)
 			if tt.expectedError {
 				assert.Error(t, err)
 				assert.Nil(t, exp)
@@ -110,6 +118,145 @@ func TestExport(t *testing.T) {
 				assert.Contains(t, output, "File excluded due to size")
 			},
 		},
+		{
+			name: "Detected languages by share",
+			flags: &types.Flags{
+				MaxTokens: intPtr(1000),
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "* Files are included based on the detected languages, by share of the repository: go (57.3%), markdown (42.7%).")
+			},
+		},
+		{
+			name: "Exclude path glob",
+			flags: &types.Flags{
+				Languages:    "go,markdown",
+				ExcludePaths: []string{"subdir/**"},
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "// File: file2.go")
+				assert.NotContains(t, output, "// File: subdir/file3.md")
+			},
+		},
+		{
+			name: "Exclude path is not a substring",
+			flags: &types.Flags{
+				Languages:    "go,markdown",
+				ExcludePaths: []string{"file"},
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "// File: file2.go")
+				assert.Contains(t, output, "// File: subdir/file3.md")
+			},
+		},
+		{
+			name: "Substring exclude compatibility",
+			flags: &types.Flags{
+				Languages:        "go,markdown",
+				ExcludePaths:     []string{"file"},
+				SubstringExclude: true,
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.NotContains(t, output, "// File: file2.go")
+				assert.NotContains(t, output, "// File: subdir/file3.md")
+			},
+		},
+		{
+			name: "Include path glob",
+			flags: &types.Flags{
+				Languages:    "go,markdown",
+				IncludePaths: []string{"**/*.md"},
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.NotContains(t, output, "// File: file2.go")
+				assert.Contains(t, output, "// File: subdir/file3.md")
+			},
+		},
+		{
+			name: "XML format",
+			flags: &types.Flags{
+				Languages: "go,markdown",
+				Format:    FormatXML,
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "<repository_export title=\"Repository Export\">")
+				assert.Contains(t, output, "path=\"file2.go\"")
+				assert.Contains(t, output, "println(\"Hello, World!\")")
+				assert.True(t, strings.HasSuffix(output, "</repository_export>\n"))
+			},
+		},
+		{
+			name: "JSON format",
+			flags: &types.Flags{
+				Languages: "go,markdown",
+				Format:    FormatJSON,
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				var doc struct {
+					Title string `json:"title"`
+					Files []struct {
+						Path    string `json:"path"`
+						Content string `json:"content"`
+					} `json:"files"`
+					Stats struct {
+						Files int `json:"files"`
+					} `json:"stats"`
+				}
+				require.NoError(t, json.Unmarshal([]byte(output), &doc))
+				assert.Equal(t, "Repository Export", doc.Title)
+				assert.Len(t, doc.Files, 2)
+				assert.Equal(t, 2, doc.Stats.Files)
+			},
+		},
+		{
+			name: "JSONL format",
+			flags: &types.Flags{
+				Languages: "go,markdown",
+				Format:    FormatJSONL,
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				lines := strings.Split(strings.TrimSpace(output), "\n")
+				require.Len(t, lines, 4)
+				for _, line := range lines {
+					assert.True(t, json.Valid([]byte(line)), line)
+				}
+				assert.Contains(t, lines[0], `"type":"header"`)
+				assert.Contains(t, lines[3], `"type":"stats"`)
+			},
+		},
+		{
+			name: "Export with a budget too small for any file",
+			flags: &types.Flags{
+				Languages: "go,markdown",
+				Budget:    intPtr(10),
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "budget of 10 tokens; 2 files were left out")
+				assert.Contains(t, output, "file2.go (")
+				assert.NotContains(t, output, "// File: file2.go")
+			},
+		},
+		{
+			name: "Export with a budget that fits everything",
+			flags: &types.Flags{
+				Languages: "go,markdown",
+				Budget:    intPtr(10000),
+			},
+			expectedError: false,
+			checkOutput: func(t *testing.T, output string) {
+				assert.Contains(t, output, "budget of 10000 tokens; all files fit")
+				assert.Contains(t, output, "// File: file2.go")
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,10 +264,10 @@ func TestExport(t *testing.T) {
 			output := filepath.Join(tempDir, "output.txt")
 			tt.flags.OutputFile = output
 
-			exp, err := New(tempDir, tt.flags)
+			exp, err := New(context.Background(), tempDir, tt.flags)
 			require.NoError(t, err)
 
-			err = exp.Export()
+			err = exp.Export(context.Background())
 			if tt.expectedError {
 				assert.Error(t, err)
 			} else {
@@ -137,3 +284,570 @@ func TestExport(t *testing.T) {
 		})
 	}
 }
+
+func TestExportChunks(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "exporter_chunks_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	for i := 0; i < 6; i++ {
+		content := fmt.Sprintf("package main\n\n// File number %d.\n%s", i, strings.Repeat("var x = 1\n", 40))
+		err := ioutil.WriteFile(filepath.Join(tempDir, fmt.Sprintf("file%d.go", i)), []byte(content), 0644)
+		require.NoError(t, err)
+	}
+
+	outDir, err := ioutil.TempDir("", "exporter_chunks_out")
+	require.NoError(t, err)
+	defer os.RemoveAll(outDir)
+
+	flags := &types.Flags{
+		Languages:   "go",
+		OutputFile:  filepath.Join(outDir, "export.md"),
+		ChunkTokens: intPtr(1200),
+	}
+
+	exp, err := New(context.Background(), tempDir, flags)
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	parts, err := filepath.Glob(filepath.Join(outDir, "export.part*.md"))
+	require.NoError(t, err)
+	require.Greater(t, len(parts), 1)
+
+	encoding, err := tiktoken.GetEncoding("")
+	require.NoError(t, err)
+
+	for i, part := range parts {
+		content, err := ioutil.ReadFile(part)
+		require.NoError(t, err)
+
+		output := string(content)
+		assert.LessOrEqual(t, encoding.Count(output), 1200)
+		assert.Contains(t, output, fmt.Sprintf("# Repository Export (Part %d of %d)", i+1, len(parts)))
+		assert.Contains(t, output, "## Repository Structure")
+		assert.Contains(t, output, fmt.Sprintf("* Part %d (this part):", i+1))
+	}
+}
+
+func TestExportChunksTooSmall(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "exporter_chunks_small")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	err = ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644)
+	require.NoError(t, err)
+
+	exp, err := New(context.Background(), tempDir, &types.Flags{
+		Languages:   "go",
+		OutputFile:  filepath.Join(tempDir, "export.md"),
+		ChunkTokens: intPtr(10),
+	})
+	require.NoError(t, err)
+	assert.Error(t, exp.Export(context.Background()))
+}
+
+func TestExportRevision(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	tempDir, err := ioutil.TempDir("", "exporter_rev_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = tempDir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	gitCmd("init", "-q")
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n\n// Released version.\n"), 0644))
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "release")
+	gitCmd("tag", "v1.0.0")
+
+	// Later work in the working tree must not show up in the export.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n\n// Work in progress.\n"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "new.go"), []byte("package main\n"), 0644))
+
+	outputFile := filepath.Join(tempDir, "output.md")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Rev: "v1.0.0", OutputFile: outputFile})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+
+	output := string(content)
+	assert.Contains(t, output, "Files are read from git revision v1.0.0")
+	assert.Contains(t, output, "// Released version.")
+	assert.NotContains(t, output, "// Work in progress.")
+	assert.NotContains(t, output, "new.go")
+
+	_, err = New(context.Background(), tempDir, &types.Flags{Rev: "v1.0.0", Staged: true})
+	assert.Error(t, err)
+
+	_, err = New(context.Background(), tempDir, &types.Flags{Rev: "v9.9.9"})
+	assert.Error(t, err)
+}
+
+func TestExportMultipleRoots(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "exporter_roots_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	testFiles := map[string]string{
+		"svc/main.go":       "package main\n",
+		"shared-lib/lib.go": "package lib\n",
+		"other/lib/lib.go":  "package other\n",
+		"lib/lib.go":        "package lib\n",
+	}
+	for path, content := range testFiles {
+		fullPath := filepath.Join(tempDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, ioutil.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	roots := []string{
+		filepath.Join(tempDir, "svc"),
+		filepath.Join(tempDir, "shared-lib"),
+		filepath.Join(tempDir, "lib"),
+		filepath.Join(tempDir, "other", "lib"),
+	}
+	outputFile := filepath.Join(tempDir, "output.md")
+	exp, err := NewWithRoots(context.Background(), roots, &types.Flags{Languages: "go", OutputFile: outputFile})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+
+	output := string(content)
+	assert.Contains(t, output, "* Files from 4 directories are included, with paths prefixed by their root: svc, shared-lib, lib, lib-2.")
+	for _, label := range []string{"svc", "shared-lib", "lib", "lib-2"} {
+		assert.Contains(t, output, "### "+label+"\n")
+	}
+	assert.Contains(t, output, "// File: svc/main.go\n")
+	assert.Contains(t, output, "// File: shared-lib/lib.go\n")
+	assert.Contains(t, output, "// File: lib/lib.go\n")
+	assert.Contains(t, output, "// File: lib-2/lib.go\n")
+
+	_, err = NewWithRoots(context.Background(), nil, &types.Flags{})
+	assert.Error(t, err)
+}
+
+func TestExportDetectsLanguageFromContent(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "exporter_detect_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempDir)
+
+	testFiles := map[string]string{
+		"bin/deploy":      "#!/usr/bin/env python3\nprint('deploy')\n",
+		"include/list.h":  "#include <vector>\nnamespace lib {\nclass List {};\n}\n",
+		"include/queue.h": "struct queue { int n; };\n",
+		"Jenkinsfile":     "pipeline {}\n",
+		"BUILD.bazel":     "go_library()\n",
+		"LICENSE":         "MIT License\n",
+	}
+	for path, content := range testFiles {
+		fullPath := filepath.Join(tempDir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, ioutil.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	outputFile := filepath.Join(tempDir, "output.md")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "python,cpp,groovy,starlark", OutputFile: outputFile})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+
+	output := string(content)
+	assert.Contains(t, output, "// File: bin/deploy\n```python\n")
+	assert.Contains(t, output, "// File: include/list.h\n```cpp\n")
+	assert.Contains(t, output, "// File: Jenkinsfile\n```groovy\n")
+	assert.Contains(t, output, "// File: BUILD.bazel\n```starlark\n")
+	assert.NotContains(t, output, "include/queue.h")
+	assert.NotContains(t, output, "LICENSE")
+}
+
+func TestExportSkipsClassifiedFiles(t *testing.T) {
+	minified := "var a=" + strings.Repeat("1+", 3000) + "1;\n"
+	testFiles := map[string]string{
+		"main.go":                   "package main\n",
+		"api.pb.go":                 "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage api\n",
+		"blob.go":                   "package blob\x00\x01\x02",
+		"vendor/lib/lib.go":         "package lib\n",
+		"web/app.js":                "console.log('app')\n",
+		"web/app.min.js":            "console.log('app')\n",
+		"web/bundle.js":             minified,
+		"web/node_modules/x/x.js":   "module.exports = {}\n",
+		"web/node_modules/x/y.js":   "module.exports = {}\n",
+		"web/package-lock.json":     "{}\n",
+		"docs/vendor/notes.md":      "# Notes\n",
+		"third_party/ignored/a.txt": "not a selected language\n",
+	}
+
+	tests := []struct {
+		name        string
+		flags       types.Flags
+		contains    []string
+		notContains []string
+	}{
+		{
+			name:  "Skipped by default",
+			flags: types.Flags{Languages: "go,js,json"},
+			contains: []string{
+				"// File: main.go",
+				"// File: web/app.js",
+				"Binary, minified, generated and vendored files are skipped: api.pb.go (generated), blob.go (binary), docs/vendor/ (vendored), third_party/ (vendored), vendor/ (vendored), web/app.min.js (minified), web/bundle.js (minified), web/node_modules/ (vendored), web/package-lock.json (generated).",
+			},
+			notContains: []string{"// File: api.pb.go", "// File: blob.go", "// File: vendor/lib/lib.go", "// File: web/app.min.js", "// File: web/bundle.js", "node_modules/x", "// File: web/package-lock.json", "third_party/ignored"},
+		},
+		{
+			name:  "Included on request",
+			flags: types.Flags{Languages: "go,js,json", IncludeGenerated: true, IncludeVendored: true},
+			contains: []string{
+				"// File: api.pb.go",
+				"// File: vendor/lib/lib.go",
+				"// File: web/app.min.js",
+				"// File: web/node_modules/x/x.js",
+				"// File: web/package-lock.json",
+				"Binary files are skipped: blob.go (binary).",
+			},
+			notContains: []string{"// File: blob.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			for path, content := range testFiles {
+				fullPath := filepath.Join(tempDir, path)
+				require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+				require.NoError(t, ioutil.WriteFile(fullPath, []byte(content), 0644))
+			}
+
+			flags := tt.flags
+			flags.OutputFile = filepath.Join(tempDir, "output.md")
+			exp, err := New(context.Background(), tempDir, &flags)
+			require.NoError(t, err)
+			require.NoError(t, exp.Export(context.Background()))
+
+			content, err := ioutil.ReadFile(flags.OutputFile)
+			require.NoError(t, err)
+
+			output := string(content)
+			for _, s := range tt.contains {
+				assert.Contains(t, output, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, output, s)
+			}
+		})
+	}
+}
+
+func TestExportStripsComments(t *testing.T) {
+	tempDir := t.TempDir()
+	source := "package main\n\n// main runs the program.\nfunc main() {\n\t// Say hello.\n\tprintln(\"// Hello\")\n\n\n}\n"
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte(source), 0644))
+
+	outputFile := filepath.Join(tempDir, "output.md")
+	flags := &types.Flags{
+		Languages:          "go",
+		OutputFile:         outputFile,
+		StripComments:      true,
+		CollapseBlankLines: true,
+		KeepDocComments:    true,
+	}
+	exp, err := New(context.Background(), tempDir, flags)
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+
+	output := string(content)
+	assert.Contains(t, output, "// main runs the program.\nfunc main() {\n\tprintln(\"// Hello\")\n\n}\n")
+	assert.NotContains(t, output, "Say hello")
+	assert.Contains(t, output, "* Comments other than doc comments are removed and runs of blank lines are collapsed.\n")
+	assert.Regexp(t, `These reductions save [1-9]\d* tokens\.`, output)
+}
+
+func TestExportSignatures(t *testing.T) {
+	tempDir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":   "package main\n\n// Run runs.\nfunc Run() {\n\tprintln(\"running\")\n}\n\nfunc main() { Run() }\n",
+		"broken.go": "package main\n\nfunc {\n",
+		"README.md": "# Readme\n",
+	}
+	for path, content := range testFiles {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644))
+	}
+
+	outputFile := filepath.Join(tempDir, "output.md")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go,markdown", OutputFile: outputFile, Signatures: true})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+
+	output := string(content)
+	assert.Contains(t, output, "// File: main.go\n```go\npackage main\n\n// Run runs.\nfunc Run()\n\n```")
+	assert.Contains(t, output, "func {")
+	assert.Contains(t, output, "# Readme")
+	assert.Contains(t, output, "* Files in go, java, js, python, ts are reduced to their declarations and doc comments")
+}
+
+func TestExportOversize(t *testing.T) {
+	var source strings.Builder
+	source.WriteString("package big\n\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&source, "// F%d does things.\nfunc F%d() int {\n\ttotal := 0\n\tfor j := 0; j < %d; j++ {\n\t\ttotal += j * j\n\t}\n\treturn total\n}\n\n", i, i, i)
+	}
+
+	tests := []struct {
+		strategy    string
+		contains    []string
+		notContains []string
+	}{
+		{
+			strategy: "",
+			contains: []string{"// File excluded due to size:", "are noted but not included."},
+		},
+		{
+			strategy:    OversizeHead,
+			contains:    []string{"; the first 100 tokens are kept.\npackage big\n\n// F0 does things.", "are truncated to their first 100 tokens."},
+			notContains: []string{"F49"},
+		},
+		{
+			strategy:    OversizeHeadTail,
+			contains:    []string{"; the first 50 and last 50 tokens are kept.\npackage big", "tokens elided ...\n", "return total\n}\n"},
+			notContains: []string{"F25"},
+		},
+		{
+			strategy: OversizeOutline,
+			contains: []string{"// File reduced to an outline due to size:", "func F49() int\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("Strategy "+tt.strategy, func(t *testing.T) {
+			tempDir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "big.go"), []byte(source.String()), 0644))
+
+			maxTokens := 100
+			if tt.strategy == OversizeOutline {
+				maxTokens = 1000
+			}
+			outputFile := filepath.Join(tempDir, "output.md")
+			exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile, MaxTokens: &maxTokens, Oversize: tt.strategy})
+			require.NoError(t, err)
+			require.NoError(t, exp.Export(context.Background()))
+
+			content, err := ioutil.ReadFile(outputFile)
+			require.NoError(t, err)
+
+			output := string(content)
+			for _, s := range tt.contains {
+				assert.Contains(t, output, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, output, s)
+			}
+		})
+	}
+
+	_, err := New(context.Background(), t.TempDir(), &types.Flags{Languages: "go", Oversize: "middle"})
+	assert.ErrorContains(t, err, `unknown oversize strategy "middle"`)
+}
+
+func TestExportIsDeterministic(t *testing.T) {
+	tempDir := t.TempDir()
+	for _, path := range []string{"z.go", "a/b.go", "a.go", "m/n/o.go", "m/a.go"} {
+		full := filepath.Join(tempDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, ioutil.WriteFile(full, []byte("package x\n"), 0644))
+	}
+
+	export := func() string {
+		outputFile := filepath.Join(t.TempDir(), "output.md")
+		exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile})
+		require.NoError(t, err)
+		require.NoError(t, exp.Export(context.Background()))
+
+		content, err := ioutil.ReadFile(outputFile)
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	first := export()
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, first, export())
+	}
+
+	last := -1
+	for _, path := range []string{"a.go", "a/b.go", "m/a.go", "m/n/o.go", "z.go"} {
+		index := strings.Index(first, "// File: "+path+"\n")
+		require.GreaterOrEqual(t, index, 0, path)
+		assert.Greater(t, index, last, path)
+		last = index
+	}
+
+	_, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", Order: "random"})
+	assert.ErrorContains(t, err, `unknown order "random"`)
+}
+
+func TestExportTreeOnly(t *testing.T) {
+	tempDir := filepath.Join(t.TempDir(), "repo")
+	for _, path := range []string{"main.go", "pkg/a/a.go", "pkg/b/b.go"} {
+		full := filepath.Join(tempDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, ioutil.WriteFile(full, []byte("package x\n"), 0644))
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "tree.txt")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile, TreeOnly: true})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "repo\n├── main.go\n└── pkg\n    ├── a\n    │   └── a.go\n    └── b\n        └── b.go\n", string(content))
+}
+
+func TestExportTreeOnlyFormats(t *testing.T) {
+	tempDir := filepath.Join(t.TempDir(), "repo")
+	require.NoError(t, os.MkdirAll(tempDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+	structure := "repo\n└── main.go\n"
+
+	tests := []struct {
+		format string
+		parse  func(t *testing.T, content []byte)
+	}{
+		{
+			format: FormatJSON,
+			parse: func(t *testing.T, content []byte) {
+				var doc struct {
+					Tree string `json:"tree"`
+				}
+				require.NoError(t, json.Unmarshal(content, &doc))
+				assert.Equal(t, structure, doc.Tree)
+			},
+		},
+		{
+			format: FormatJSONL,
+			parse: func(t *testing.T, content []byte) {
+				var record struct {
+					Type string `json:"type"`
+					Tree string `json:"tree"`
+				}
+				require.NoError(t, json.Unmarshal(content, &record))
+				assert.Equal(t, "tree", record.Type)
+				assert.Equal(t, structure, record.Tree)
+			},
+		},
+		{
+			format: FormatXML,
+			parse: func(t *testing.T, content []byte) {
+				var doc struct {
+					Structures []string `xml:"repository_structure"`
+				}
+				require.NoError(t, xml.Unmarshal(content, &doc))
+				assert.Equal(t, []string{"\n" + structure}, doc.Structures)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			outputFile := filepath.Join(t.TempDir(), "tree")
+			exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile, TreeOnly: true, Format: tt.format})
+			require.NoError(t, err)
+			require.NoError(t, exp.Export(context.Background()))
+
+			content, err := ioutil.ReadFile(outputFile)
+			require.NoError(t, err)
+			tt.parse(t, content)
+		})
+	}
+}
+
+func TestExportTreeFiltered(t *testing.T) {
+	tempDir := filepath.Join(t.TempDir(), "repo")
+	files := map[string]string{
+		".gitignore":    "build/\n",
+		"main.go":       "package main\n",
+		"notes.txt":     "notes\n",
+		"build/out.go":  "package build\n",
+		"pkg/image.png": "\x89PNG\r\n\x1a\n\x00\x00",
+	}
+	for path, content := range files {
+		full := filepath.Join(tempDir, filepath.FromSlash(path))
+		require.NoError(t, os.MkdirAll(filepath.Dir(full), 0755))
+		require.NoError(t, ioutil.WriteFile(full, []byte(content), 0644))
+	}
+
+	outputFile := filepath.Join(t.TempDir(), "tree.txt")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile, UseGitIgnore: true, TreeOnly: true, TreeFiltered: true})
+	require.NoError(t, err)
+	require.NoError(t, exp.Export(context.Background()))
+
+	content, err := ioutil.ReadFile(outputFile)
+	require.NoError(t, err)
+	assert.Equal(t, "repo\n├── .gitignore\n├── main.go\n├── ⊘ build\n├── ⊖ notes.txt\n└── pkg\n    └── ⊖ image.png\n\n⊘ ignored by .gitignore, ⊖ filtered out or skipped\n", string(content))
+}
+
+func TestExportCancelled(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+
+	outputFile := filepath.Join(t.TempDir(), "output.md")
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go", OutputFile: outputFile})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, exp.Export(ctx), context.Canceled)
+
+	_, err = os.Stat(outputFile)
+	assert.True(t, os.IsNotExist(err))
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("no space left on device")
+}
+
+func TestExportReportsWriteErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+
+	exp, err := New(context.Background(), tempDir, &types.Flags{Languages: "go"})
+	require.NoError(t, err)
+	exp.writer = NewWriter(failingWriter{}, exp.format)
+
+	err = exp.Export(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no space left on device")
+}
+
+func TestNewDoesNotChangeFlags(t *testing.T) {
+	tempDir := t.TempDir()
+	require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+
+	flags := &types.Flags{}
+	exp, err := New(context.Background(), tempDir, flags)
+	require.NoError(t, err)
+	defer closeRoots(exp.roots)
+
+	assert.Equal(t, "go", exp.flags.Languages)
+	assert.Empty(t, flags.Languages)
+}
@@ -3,19 +3,37 @@
 package exporter
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/daemonp/gogpt/pkg/fileutils"
+	"github.com/daemonp/gogpt/pkg/classifier"
+	"github.com/daemonp/gogpt/pkg/git"
 	"github.com/daemonp/gogpt/pkg/gitignore"
+	"github.com/daemonp/gogpt/pkg/languagedetector"
+	"github.com/daemonp/gogpt/pkg/pathmatch"
 	"github.com/daemonp/gogpt/pkg/tiktoken"
 	"github.com/daemonp/gogpt/pkg/types"
+	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+// DefaultReadTimeout is how long reading a file from disk may take before
+// it is given up on.
+const DefaultReadTimeout = 30 * time.Second
+
+// streamBuffer is the capacity of the channels between the stages of the
+// export pipeline, which bounds the files held between two stages.
+const streamBuffer = 64
+
 type FileProcessor struct {
 	rootDir        string
 	languages      []string
@@ -23,7 +41,69 @@ type FileProcessor struct {
 	gitIgnore      *gitignore.GitIgnore
 	useGitIgnore   bool
 	customScanFunc func() ([]FileInfo, error)
-	excludePaths   []string
+	includes       *pathmatch.Matcher
+	excludes       *pathmatch.Matcher
+	// substringExcludes holds the exclude paths when they are matched as
+	// plain substrings of the path, as gogpt did before globs.
+	substringExcludes []string
+	encoding          *tiktoken.Encoding
+	repo              *git.Repository
+	changeSet         git.ChangeSet
+	includeDiff       bool
+	includeGenerated  bool
+	includeVendored   bool
+	oversize          string
+
+	// fsys holds the files to scan, rooted at rootDir: the directory on disk
+	// unless SetFS gives another filesystem.
+	fsys fs.FS
+
+	// jobs is the number of files processed at once.
+	jobs int
+	// readTimeout bounds the time to read a file from disk.
+	readTimeout time.Duration
+	// recordFiltered keeps the files left out by .gitignore and the filters
+	// so the tree can show them.
+	recordFiltered bool
+	logger         zerolog.Logger
+
+	skippedMu sync.Mutex
+	skipped   map[string]string
+	filtered  map[string]string
+	failed    map[string]error
+}
+
+// Reasons a file or directory is filtered out before its content is read.
+const (
+	// FilteredGitIgnore is a path ignored by .gitignore.
+	FilteredGitIgnore = "gitignore"
+	// FilteredOut is a file not selected by the languages or the include and
+	// exclude paths.
+	FilteredOut = "filtered"
+)
+
+// FileError is a file that could not be read or processed, and was left out
+// of the export.
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e FileError) Error() string {
+	return fmt.Sprintf("%s: %v", filepath.ToSlash(e.Path), e.Err)
+}
+
+func (e FileError) Unwrap() error {
+	return e.Err
+}
+
+// SkippedFile is a file, or a directory, left out of the export.
+type SkippedFile struct {
+	Path string
+	// Reason is one of the classifier kinds, e.g. classifier.Binary, for
+	// files skipped because of what they contain, or FilteredGitIgnore or
+	// FilteredOut for files left out by the filters.
+	Reason string
 }
 
 type FileInfo struct {
@@ -31,87 +111,400 @@ type FileInfo struct {
 	Content    []byte
 	TokenCount int
 	Excluded   bool
+	// Language is the detected language of the file, or "" if unknown.
+	Language string
+	// Segment names the part of the file held in Content when it had to be
+	// split across chunks, e.g. "lines 1-120 of 480".
+	Segment string
+	// Status is the git status of the file when exporting a change set.
+	Status string
+	// Diff is the file's unified diff when exporting a change set with diffs.
+	Diff []byte
+	// TokensSaved is the number of tokens removed by content reductions such
+	// as comment stripping.
+	TokensSaved int
+	// ModTime is the modification time of the file in the working tree, or
+	// zero when it is read from git objects.
+	ModTime time.Time
+	// spooled locates the content once it has been moved to the spool, when
+	// Content is nil.
+	spooled *spoolSpan
 }
 
-func NewFileProcessor(rootDir string, flags *types.Flags, gitIgnore *gitignore.GitIgnore) *FileProcessor {
-	return &FileProcessor{
+// contentSize returns the size of the file's content, wherever it is held.
+func (f FileInfo) contentSize() int {
+	if f.spooled != nil {
+		return f.spooled.length
+	}
+	return len(f.Content)
+}
+
+func NewFileProcessor(rootDir string, flags *types.Flags, gitIgnore *gitignore.GitIgnore, encoding *tiktoken.Encoding) (*FileProcessor, error) {
+	includes, err := pathmatch.New(flags.IncludePaths)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include path: %w", err)
+	}
+
+	fp := &FileProcessor{
 		rootDir:      rootDir,
+		fsys:         os.DirFS(rootDir),
 		languages:    strings.Split(flags.Languages, ","),
 		maxTokens:    flags.MaxTokens,
 		gitIgnore:    gitIgnore,
 		useGitIgnore: flags.UseGitIgnore,
-		excludePaths: flags.ExcludePaths,
+		includes:     includes,
+		encoding:     encoding,
+
+		includeGenerated: flags.IncludeGenerated,
+		includeVendored:  flags.IncludeVendored,
+		oversize:         flags.Oversize,
+		jobs:             runtime.NumCPU(),
+		readTimeout:      readTimeout(flags),
+		recordFiltered:   flags.TreeFiltered,
+		skipped:          make(map[string]string),
+		filtered:         make(map[string]string),
+		failed:           make(map[string]error),
+		logger:           log.Logger,
 	}
+
+	if flags.Jobs != nil {
+		fp.jobs = *flags.Jobs
+	}
+	if fp.oversize == "" {
+		fp.oversize = OversizeExclude
+	}
+	if err := validateOversize(fp.oversize); err != nil {
+		return nil, err
+	}
+
+	if flags.SubstringExclude {
+		fp.substringExcludes = flags.ExcludePaths
+	} else {
+		fp.excludes, err = pathmatch.New(flags.ExcludePaths)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude path: %w", err)
+		}
+	}
+
+	return fp, nil
 }
 
 func (fp *FileProcessor) SetCustomScanFunc(scanFunc func() ([]FileInfo, error)) {
 	fp.customScanFunc = scanFunc
 }
 
-func (fp *FileProcessor) ScanFiles() ([]FileInfo, error) {
-	if fp.customScanFunc != nil {
-		return fp.customScanFunc()
-	}
+// SetChangeSet restricts the scan to the files touched by a git change set,
+// read as they are in that change set, optionally with their diffs.
+func (fp *FileProcessor) SetChangeSet(repo *git.Repository, changeSet git.ChangeSet, includeDiff bool) {
+	fp.repo = repo
+	fp.changeSet = changeSet
+	fp.includeDiff = includeDiff
+	fp.fsys = repo.ChangeSetFS(changeSet)
+}
+
+// SetLogger logs to logger instead of the global logger.
+func (fp *FileProcessor) SetLogger(logger zerolog.Logger) {
+	fp.logger = logger
+}
+
+// SetFS scans a filesystem instead of the directory on disk, such as the
+// tree of a git revision, an archive or an in-memory filesystem. The paths
+// of its files are reported as if it were mounted at the root directory.
+func (fp *FileProcessor) SetFS(fsys fs.FS) {
+	fp.fsys = fsys
+}
+
+// ScanFiles returns the selected files sorted by path.
+func (fp *FileProcessor) ScanFiles(ctx context.Context) ([]FileInfo, error) {
+	out := make(chan FileInfo, streamBuffer)
+	done := make(chan error, 1)
+	go func() {
+		done <- fp.Stream(ctx, out)
+	}()
 
 	var files []FileInfo
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	for file := range out {
+		files = append(files, file)
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+
+	sortByPath(files)
+	return files, nil
+}
 
-	err := filepath.Walk(fp.rootDir, func(path string, info os.FileInfo, err error) error {
+// scanItem is a file to process, as listed by the walk, the revision's tree
+// or the change set.
+type scanItem struct {
+	path   string
+	status string
+	diff   []byte
+}
+
+// Stream processes the selected files and sends each to out as soon as it
+// is ready, in no particular order, closing out when it returns. Listing the
+// files and processing them are separate stages, with a fixed number of
+// workers reading, filtering and counting the tokens of files, so memory is
+// bounded by the files in flight rather than the size of the tree. When
+// ctx is cancelled the stages stop and Stream returns ctx's error.
+func (fp *FileProcessor) Stream(ctx context.Context, out chan<- FileInfo) error {
+	defer close(out)
+
+	if fp.customScanFunc != nil {
+		files, err := fp.customScanFunc()
 		if err != nil {
 			return err
 		}
-
-		if info.IsDir() || fp.shouldIgnoreFile(path) {
-			return nil
+		for _, file := range files {
+			if !send(ctx, out, file) {
+				return ctx.Err()
+			}
 		}
+		return nil
+	}
+
+	items := make(chan scanItem, streamBuffer)
+	var listErr error
+	go func() {
+		defer close(items)
+		listErr = fp.list(ctx, items)
+	}()
 
+	var wg sync.WaitGroup
+	for i := 0; i < fp.jobs; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-
-			relPath, err := filepath.Rel(fp.rootDir, path)
-			if err != nil {
-				log.Error().Err(err).Str("file", path).Msg("Failed to get relative path")
-				return
+			for item := range items {
+				if fileInfo, ok := fp.processItem(ctx, item); ok && !send(ctx, out, fileInfo) {
+					return
+				}
 			}
+		}()
+	}
+	wg.Wait()
 
-			fileInfo, err := fp.processFile(relPath)
-			if err != nil {
-				log.Error().Err(err).Str("file", relPath).Msg("Failed to process file")
-				return
-			}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if listErr != nil {
+		return listErr
+	}
 
-			mu.Lock()
-			files = append(files, fileInfo)
-			mu.Unlock()
-		}()
+	// Change sets only include the changed files.
+	if fp.repo == nil {
+		fp.streamSpecialFiles(ctx, out)
+	}
+	return ctx.Err()
+}
+
+// send sends a value to a pipeline stage, and reports whether it was sent
+// before ctx was cancelled.
+func send[T any](ctx context.Context, ch chan<- T, value T) bool {
+	select {
+	case ch <- value:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// list sends the files to process to items.
+func (fp *FileProcessor) list(ctx context.Context, items chan<- scanItem) error {
+	if fp.repo != nil {
+		return fp.listChanges(ctx, items)
+	}
 
+	// Files are filtered by their full path under the root directory.
+	err := fs.WalkDir(fp.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath := filepath.FromSlash(name)
+		path := filepath.Join(fp.rootDir, relPath)
+		if d.IsDir() {
+			return fp.walkDir(relPath, path, d)
+		}
+		if reason := fp.ignoreReason(path); reason != "" {
+			if reason != classifier.Vendored {
+				fp.filter(path, reason)
+			}
+			return nil
+		}
+
+		if !send(ctx, items, scanItem{path: relPath}) {
+			return ctx.Err()
+		}
 		return nil
 	})
+	if err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+	return nil
+}
 
-	wg.Wait()
+// walkDir skips the .git directory and directories ignored by .gitignore,
+// as git does not look for files to include within them, and vendored
+// directories unless they are included.
+func (fp *FileProcessor) walkDir(relPath, path string, d fs.DirEntry) error {
+	if path == fp.rootDir {
+		return nil
+	}
+	if d.Name() == ".git" {
+		return fs.SkipDir
+	}
+	if fp.useGitIgnore && fp.gitIgnore != nil && fp.gitIgnore.ShouldIgnore(path) {
+		fp.filter(path, FilteredGitIgnore)
+		return fs.SkipDir
+	}
+	if !fp.includeVendored && classifier.IsVendorDir(d.Name()) {
+		fp.skip(relPath, classifier.Vendored)
+		return fs.SkipDir
+	}
+	return nil
+}
 
+func (fp *FileProcessor) listChanges(ctx context.Context, items chan<- scanItem) error {
+	changes, err := fp.repo.Changes(fp.changeSet)
 	if err != nil {
-		return nil, fmt.Errorf("failed to walk directory: %w", err)
+		return fmt.Errorf("failed to list changed files: %w", err)
 	}
 
-	return fp.includeSpecialFiles(files), nil
+	var diffs map[string][]byte
+	if fp.includeDiff {
+		diffs, err = fp.repo.Diffs(fp.changeSet)
+		if err != nil {
+			return fmt.Errorf("failed to read diffs: %w", err)
+		}
+	}
+
+	for _, change := range changes {
+		relPath := filepath.FromSlash(change.Path)
+		if fp.shouldIgnoreFile(filepath.Join(fp.rootDir, relPath)) {
+			continue
+		}
+		if !send(ctx, items, scanItem{path: relPath, status: change.Status, diff: diffs[change.Path]}) {
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
+
+// processItem reads and processes a listed file, and reports whether it is
+// selected. Deleted files in a change set are noted without being read.
+func (fp *FileProcessor) processItem(ctx context.Context, item scanItem) (FileInfo, bool) {
+	var fileInfo FileInfo
+	if item.status == git.StatusDeleted {
+		language, _ := languagedetector.DetectByPath(item.path)
+		if !fp.isLanguageIncluded(language) {
+			return FileInfo{}, false
+		}
+		content := []byte("// File deleted")
+		fileInfo = FileInfo{Path: item.path, Content: content, TokenCount: fp.encoding.Count(string(content)), Excluded: true, Language: language}
+	} else {
+		var ok bool
+		var err error
+		fileInfo, ok, err = fp.processSelectedFile(ctx, item.path)
+		if err != nil {
+			fp.fail(item.path, err)
+			return FileInfo{}, false
+		}
+		if !ok {
+			return FileInfo{}, false
+		}
+	}
+
+	fileInfo.Status = item.status
+	fileInfo.Diff = item.diff
+	return fileInfo, true
+}
+
+// readFile reads a file from the scanned filesystem, or the change set's.
+func (fp *FileProcessor) readFile(ctx context.Context, path string) ([]byte, error) {
+	return readFSFile(ctx, fp.fsys, filepath.ToSlash(path), fp.readTimeout)
+}
+
+// readFSFile reads a file from a filesystem, giving up after timeout so a
+// FIFO or a stalled network mount cannot hang the export. A read that times
+// out cannot be interrupted and is left to finish in the background.
+func readFSFile(ctx context.Context, fsys fs.FS, name string, timeout time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		content []byte
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		content, err := fs.ReadFile(fsys, name)
+		done <- result{content, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.content, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out after %s", timeout)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// readTimeout returns the time a file may take to read.
+func readTimeout(flags *types.Flags) time.Duration {
+	if flags.ReadTimeout > 0 {
+		return flags.ReadTimeout
+	}
+	return DefaultReadTimeout
 }
 
-func (fp *FileProcessor) processFile(path string) (FileInfo, error) {
-	content, err := os.ReadFile(filepath.Join(fp.rootDir, path))
+func (fp *FileProcessor) processFile(ctx context.Context, path string) (FileInfo, error) {
+	content, err := fp.readFile(ctx, path)
 	if err != nil {
 		return FileInfo{}, fmt.Errorf("failed to read file: %w", err)
 	}
 
-	tokenCount := tiktoken.CountTokens(string(content))
+	return fp.newFileInfo(path, content, languagedetector.Detect(path, content)), nil
+}
+
+// processSelectedFile processes a file only if its detected language is one
+// of the selected languages, and reports whether it is.
+func (fp *FileProcessor) processSelectedFile(ctx context.Context, path string) (FileInfo, bool, error) {
+	content, err := fp.readFile(ctx, path)
+	if err != nil {
+		return FileInfo{}, false, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	language := languagedetector.Detect(path, content)
+	if !fp.isLanguageIncluded(language) {
+		fp.filter(filepath.Join(fp.rootDir, path), FilteredOut)
+		return FileInfo{}, false, nil
+	}
+
+	if reason := classifier.Classify(path, content); reason == classifier.Binary || reason != "" && !fp.includeGenerated {
+		fp.skip(path, reason)
+		return FileInfo{}, false, nil
+	}
+	return fp.newFileInfo(path, content, language), true, nil
+}
+
+func (fp *FileProcessor) newFileInfo(path string, content []byte, language string) FileInfo {
+	tokenCount := fp.encoding.Count(string(content))
 	excluded := false
 
 	if fp.maxTokens != nil && tokenCount > *fp.maxTokens {
-		excluded = true
-		log.Warn().Str("file", path).Int("tokens", tokenCount).Msg("File excluded due to size")
-		content = []byte(fmt.Sprintf("// File excluded due to size: %d tokens", tokenCount))
+		content, excluded = fp.shorten(path, language, content, tokenCount)
+		if excluded {
+			fp.logger.Warn().Str("file", path).Int("tokens", tokenCount).Msg("File excluded due to size")
+		} else {
+			fp.logger.Warn().Str("file", path).Int("tokens", tokenCount).Str("oversize", fp.oversize).Msg("File shortened due to size")
+			tokenCount = fp.encoding.Count(string(content))
+		}
 	}
 
 	return FileInfo{
@@ -119,32 +512,148 @@ func (fp *FileProcessor) processFile(path string) (FileInfo, error) {
 		Content:    content,
 		TokenCount: tokenCount,
 		Excluded:   excluded,
-	}, nil
+		Language:   language,
+		ModTime:    fp.modTime(path),
+	}
 }
 
 func (fp *FileProcessor) shouldIgnoreFile(path string) bool {
+	return fp.ignoreReason(path) != ""
+}
+
+// ignoreReason returns why a file is left out before its content is read:
+// FilteredGitIgnore, FilteredOut or classifier.Vendored, or "" if it is not.
+func (fp *FileProcessor) ignoreReason(path string) string {
 	if fp.useGitIgnore && fp.gitIgnore != nil && fp.gitIgnore.ShouldIgnore(path) {
-		return true
+		return FilteredGitIgnore
+	}
+
+	relPath, err := filepath.Rel(fp.rootDir, path)
+	if err != nil {
+		fp.logger.Error().Err(err).Str("path", path).Msg("Failed to get relative path")
+		return FilteredOut
 	}
+	relPath = filepath.ToSlash(relPath)
 
 	// Check if the path should be excluded
-	for _, excludePath := range fp.excludePaths {
+	for _, excludePath := range fp.substringExcludes {
 		if strings.Contains(path, excludePath) {
-			return true
+			return FilteredOut
 		}
 	}
+	if fp.excludes != nil && fp.excludes.Match(relPath) {
+		return FilteredOut
+	}
+	if !fp.includes.Empty() && !fp.includes.Match(relPath) {
+		return FilteredOut
+	}
 
-	ext := fileutils.GetFileExtension(path)
-	for _, lang := range fp.languages {
-		if fileutils.IsLanguageFile(lang, ext) {
-			return false
+	// Files whose language is not certain from the path are checked again
+	// once their content has been read.
+	if lang, certain := languagedetector.DetectByPath(path); certain && !fp.isLanguageIncluded(lang) {
+		return FilteredOut
+	}
+
+	if !fp.includeVendored {
+		if dir := classifier.VendoredDir(relPath); dir != "" {
+			fp.skip(filepath.FromSlash(dir), classifier.Vendored)
+			return classifier.Vendored
 		}
 	}
 
-	return true
+	return ""
+}
+
+// skip records a file left out because of its content, or a vendored
+// directory. Each path is recorded once.
+func (fp *FileProcessor) skip(path, reason string) {
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+
+	if _, ok := fp.skipped[path]; !ok {
+		fp.logger.Debug().Str("path", path).Str("reason", reason).Msg("Skipping file")
+		fp.skipped[path] = reason
+	}
+}
+
+// Skipped returns the files and vendored directories skipped by the last
+// scan, sorted by path.
+func (fp *FileProcessor) Skipped() []SkippedFile {
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+
+	skipped := make([]SkippedFile, 0, len(fp.skipped))
+	for path, reason := range fp.skipped {
+		skipped = append(skipped, SkippedFile{Path: path, Reason: reason})
+	}
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Path < skipped[j].Path
+	})
+	return skipped
+}
+
+// filter records a file or directory, given by its full path, left out by
+// .gitignore or the filters, when the tree is to show them.
+func (fp *FileProcessor) filter(path, reason string) {
+	if !fp.recordFiltered {
+		return
+	}
+	relPath, err := filepath.Rel(fp.rootDir, path)
+	if err != nil {
+		return
+	}
+
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+	fp.filtered[relPath] = reason
+}
+
+// Filtered returns the files and directories left out by .gitignore and the
+// filters in the last scan, sorted by path. They are only recorded when the
+// tree shows filtered files.
+func (fp *FileProcessor) Filtered() []SkippedFile {
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+
+	filtered := make([]SkippedFile, 0, len(fp.filtered))
+	for path, reason := range fp.filtered {
+		filtered = append(filtered, SkippedFile{Path: path, Reason: reason})
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Path < filtered[j].Path
+	})
+	return filtered
+}
+
+// fail records a file, given by its path relative to the root, that could
+// not be read or processed.
+func (fp *FileProcessor) fail(path string, err error) {
+	fp.logger.Error().Err(err).Str("file", path).Msg("Failed to process file")
+
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+	fp.failed[path] = err
+}
+
+// Failed returns the files that could not be read or processed in the last
+// scan, sorted by path.
+func (fp *FileProcessor) Failed() []FileError {
+	fp.skippedMu.Lock()
+	defer fp.skippedMu.Unlock()
+
+	failed := make([]FileError, 0, len(fp.failed))
+	for path, err := range fp.failed {
+		failed = append(failed, FileError{Path: path, Err: err})
+	}
+	sort.Slice(failed, func(i, j int) bool {
+		return failed[i].Path < failed[j].Path
+	})
+	return failed
 }
 
-func (fp *FileProcessor) includeSpecialFiles(files []FileInfo) []FileInfo {
+// streamSpecialFiles sends the files included whatever the filters, such as
+// .gitignore, to out.
+func (fp *FileProcessor) streamSpecialFiles(ctx context.Context, out chan<- FileInfo) {
 	specialFiles := []string{
 		".gitignore",
 	}
@@ -155,27 +664,38 @@ func (fp *FileProcessor) includeSpecialFiles(files []FileInfo) []FileInfo {
 	}
 
 	for _, specialFile := range specialFiles {
-		filePath := filepath.Join(fp.rootDir, specialFile)
-		if _, err := os.Stat(filePath); err == nil {
-			relPath, err := filepath.Rel(fp.rootDir, filePath)
-			if err != nil {
-				log.Error().Err(err).Str("file", filePath).Msg("Failed to get relative path for special file")
-				continue
-			}
+		if !fp.exists(specialFile) {
+			continue
+		}
 
-			fileInfo, err := fp.processFile(relPath)
-			if err != nil {
-				log.Error().Err(err).Str("file", relPath).Msg("Failed to process special file")
-				continue
-			}
-			files = append(files, fileInfo)
+		fileInfo, err := fp.processFile(ctx, specialFile)
+		if err != nil {
+			fp.fail(specialFile, err)
+			continue
+		}
+		if !send(ctx, out, fileInfo) {
+			return
 		}
 	}
+}
 
-	return files
+func (fp *FileProcessor) modTime(path string) time.Time {
+	info, err := fs.Stat(fp.fsys, filepath.ToSlash(path))
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (fp *FileProcessor) exists(path string) bool {
+	_, err := fs.Stat(fp.fsys, filepath.ToSlash(path))
+	return err == nil
 }
 
 func (fp *FileProcessor) isLanguageIncluded(lang string) bool {
+	if lang == "" {
+		return false
+	}
 	for _, l := range fp.languages {
 		if strings.TrimSpace(strings.ToLower(l)) == lang {
 			return true
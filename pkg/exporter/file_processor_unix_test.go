@@ -0,0 +1,75 @@
+// File: pkg/exporter/file_processor_unix_test.go
+
+//go:build unix
+
+package exporter
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/daemonp/gogpt/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportSkipsFilesThatTimeOut(t *testing.T) {
+	tests := []struct {
+		name      string
+		languages string
+		strict    bool
+		pipes     []string
+	}{
+		{name: "Lenient", languages: "go", pipes: []string{"pipe.go"}},
+		{name: "Strict", languages: "go", strict: true, pipes: []string{"pipe.go"}},
+		// Detecting the languages reads the files without an extension too.
+		{name: "Detected languages", pipes: []string{"pipe.go", "run"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+			require.NoError(t, ioutil.WriteFile(filepath.Join(tempDir, "main.go"), []byte("package main\n"), 0644))
+
+			// Opening a named pipe blocks until it has a writer.
+			for _, name := range tt.pipes {
+				pipe := filepath.Join(tempDir, name)
+				require.NoError(t, syscall.Mkfifo(pipe, 0644))
+				defer func() {
+					// Unblock the abandoned reads.
+					for range 2 {
+						if f, err := os.OpenFile(pipe, os.O_RDWR, 0); err == nil {
+							f.Close()
+						}
+					}
+				}()
+			}
+
+			outputFile := filepath.Join(t.TempDir(), "output.md")
+			exp, err := New(context.Background(), tempDir, &types.Flags{Languages: tt.languages, OutputFile: outputFile, ReadTimeout: 50 * time.Millisecond, Strict: tt.strict})
+			require.NoError(t, err)
+
+			var scanErr *ScanError
+			require.ErrorAs(t, exp.Export(context.Background()), &scanErr)
+			require.Len(t, scanErr.Failed, len(tt.pipes))
+			assert.Equal(t, "pipe.go", scanErr.Failed[0].Path)
+			assert.Contains(t, scanErr.Error(), "pipe.go: failed to read file: timed out after 50ms")
+			assert.Equal(t, !tt.strict, scanErr.Written)
+
+			content, err := ioutil.ReadFile(outputFile)
+			if tt.strict {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "// File: main.go")
+			assert.Contains(t, string(content), "Files that could not be read are left out: pipe.go (failed to read file: timed out after 50ms)")
+			assert.NotContains(t, string(content), "// File: pipe.go")
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	FormatMarkdown = "markdown"
+	FormatXML      = "xml"
+	FormatJSON     = "json"
+	FormatJSONL    = "jsonl"
+)
+
+const exportDescription = "This document is a structured representation of the contents of the repository. It includes a list of files and their contents as per the following criteria:"
+
+// Header is everything written before the file contents.
+type Header struct {
+	Title    string
+	Criteria []string
+	Dropped  []DroppedFile
+	Trees    []Tree
+}
+
+// Tree is the directory structure of one root. Label is only set when the
+// export has more than one root.
+type Tree struct {
+	Label     string
+	Structure string
+}
+
+// Summary is everything written after the file contents. Chunks is only set
+// when the export is split into parts, with Part the index of the current one.
+type Summary struct {
+	Files       int
+	TotalSize   int64
+	TotalTokens int
+	Chunks      []Chunk
+	Part        int
+}
+
+// Format renders an export in three stages so files can be written as they
+// are produced. Formats are stateless; index is the 1-based position of the
+// file within the current output.
+type Format interface {
+	Extension() string
+	WriteHeader(w io.Writer, header Header) error
+	WriteFile(w io.Writer, file FileInfo, index int) error
+	WriteFooter(w io.Writer, summary Summary) error
+	// WriteTrees writes a document holding only the directory structure of
+	// every root, for --tree-only.
+	WriteTrees(w io.Writer, trees []Tree) error
+}
+
+// NewFormat returns the named output format. An empty name selects Markdown.
+func NewFormat(name string) (Format, error) {
+	switch name {
+	case "", FormatMarkdown:
+		return &MarkdownFormat{}, nil
+	case FormatXML:
+		return &XMLFormat{}, nil
+	case FormatJSON:
+		return &JSONFormat{}, nil
+	case FormatJSONL:
+		return &JSONLFormat{}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q (supported: %s, %s, %s, %s)", name, FormatMarkdown, FormatXML, FormatJSON, FormatJSONL)
+	}
+}
+
+// fileNotes lists the parenthetical notes that follow a file's path.
+func fileNotes(file FileInfo) []string {
+	var notes []string
+	if file.Segment != "" {
+		notes = append(notes, file.Segment)
+	}
+	if file.Status != "" {
+		notes = append(notes, file.Status)
+	}
+	return notes
+}
+
+func chunkNames(chunk Chunk) []string {
+	names := make([]string, len(chunk.Files))
+	for i, file := range chunk.Files {
+		names[i] = file.Path
+		if file.Segment != "" {
+			names[i] = fmt.Sprintf("%s (%s)", file.Path, file.Segment)
+		}
+	}
+	return names
+}
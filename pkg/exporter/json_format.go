@@ -0,0 +1,208 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonHeader struct {
+	Title    string        `json:"title"`
+	Criteria []string      `json:"criteria"`
+	Dropped  []jsonDropped `json:"dropped,omitempty"`
+	Tree     string        `json:"tree,omitempty"`
+	Roots    []jsonRoot    `json:"roots,omitempty"`
+}
+
+type jsonRoot struct {
+	Root string `json:"root"`
+	Tree string `json:"tree"`
+}
+
+type jsonDropped struct {
+	Path   string `json:"path"`
+	Tokens int    `json:"tokens"`
+	Reason string `json:"reason"`
+}
+
+type jsonFile struct {
+	Path     string `json:"path"`
+	Segment  string `json:"segment,omitempty"`
+	Status   string `json:"status,omitempty"`
+	Tokens   int    `json:"tokens"`
+	Excluded bool   `json:"excluded,omitempty"`
+	Content  string `json:"content"`
+	Diff     string `json:"diff,omitempty"`
+}
+
+type jsonStats struct {
+	Files       int   `json:"files"`
+	TotalSize   int64 `json:"total_size"`
+	TotalTokens int   `json:"total_tokens"`
+}
+
+type jsonPart struct {
+	Part    int      `json:"part"`
+	Current bool     `json:"current,omitempty"`
+	Files   []string `json:"files"`
+}
+
+// jsonTrees holds the structure of the roots in a "tree" field for a single
+// root, or a "roots" list for several.
+type jsonTrees struct {
+	Tree  string     `json:"tree,omitempty"`
+	Roots []jsonRoot `json:"roots,omitempty"`
+}
+
+func newJSONTrees(trees []Tree) jsonTrees {
+	var t jsonTrees
+	if len(trees) == 1 && trees[0].Label == "" {
+		t.Tree = trees[0].Structure
+	} else {
+		for _, tree := range trees {
+			t.Roots = append(t.Roots, jsonRoot{Root: tree.Label, Tree: tree.Structure})
+		}
+	}
+	return t
+}
+
+func newJSONHeader(header Header) jsonHeader {
+	trees := newJSONTrees(header.Trees)
+	h := jsonHeader{
+		Title:    header.Title,
+		Criteria: header.Criteria,
+		Tree:     trees.Tree,
+		Roots:    trees.Roots,
+	}
+	for _, d := range header.Dropped {
+		h.Dropped = append(h.Dropped, jsonDropped{Path: d.Path, Tokens: d.TokenCount, Reason: d.Reason})
+	}
+	return h
+}
+
+func newJSONFile(file FileInfo) jsonFile {
+	return jsonFile{
+		Path:     file.Path,
+		Segment:  file.Segment,
+		Status:   file.Status,
+		Tokens:   file.TokenCount,
+		Excluded: file.Excluded,
+		Content:  string(file.Content),
+		Diff:     string(file.Diff),
+	}
+}
+
+func newJSONManifest(summary Summary) []jsonPart {
+	if summary.Chunks == nil {
+		return nil
+	}
+	parts := make([]jsonPart, len(summary.Chunks))
+	for i, chunk := range summary.Chunks {
+		parts[i] = jsonPart{Part: i + 1, Current: i == summary.Part, Files: chunkNames(chunk)}
+	}
+	return parts
+}
+
+// JSONFormat writes a single JSON object with the header fields, a "files"
+// array and the stats. It is streamed: the object is opened by WriteHeader
+// and closed by WriteFooter.
+type JSONFormat struct{}
+
+func (f *JSONFormat) Extension() string {
+	return ".json"
+}
+
+func (f *JSONFormat) WriteHeader(w io.Writer, header Header) error {
+	data, err := json.Marshal(newJSONHeader(header))
+	if err != nil {
+		return err
+	}
+	// Reopen the marshalled object so the files can follow.
+	_, err = fmt.Fprintf(w, "%s,\"files\":[\n", data[:len(data)-1])
+	return err
+}
+
+func (f *JSONFormat) WriteFile(w io.Writer, file FileInfo, index int) error {
+	data, err := json.Marshal(newJSONFile(file))
+	if err != nil {
+		return err
+	}
+	separator := ""
+	if index > 1 {
+		separator = ","
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", separator, data)
+	return err
+}
+
+func (f *JSONFormat) WriteFooter(w io.Writer, summary Summary) error {
+	data, err := json.Marshal(struct {
+		Stats    jsonStats  `json:"stats"`
+		Manifest []jsonPart `json:"manifest,omitempty"`
+	}{
+		Stats:    jsonStats{Files: summary.Files, TotalSize: summary.TotalSize, TotalTokens: summary.TotalTokens},
+		Manifest: newJSONManifest(summary),
+	})
+	if err != nil {
+		return err
+	}
+	// Splice the footer fields into the object opened by WriteHeader.
+	_, err = fmt.Fprintf(w, "],%s\n", data[1:])
+	return err
+}
+
+// WriteTrees writes a JSON object with the "tree" or "roots" field alone.
+func (f *JSONFormat) WriteTrees(w io.Writer, trees []Tree) error {
+	return writeJSONLine(w, newJSONTrees(trees))
+}
+
+// JSONLFormat writes one JSON object per line: a "header" record, one "file"
+// record per file and a closing "stats" record.
+type JSONLFormat struct{}
+
+func (f *JSONLFormat) Extension() string {
+	return ".jsonl"
+}
+
+func (f *JSONLFormat) WriteHeader(w io.Writer, header Header) error {
+	return writeJSONLine(w, struct {
+		Type string `json:"type"`
+		jsonHeader
+	}{"header", newJSONHeader(header)})
+}
+
+func (f *JSONLFormat) WriteFile(w io.Writer, file FileInfo, index int) error {
+	return writeJSONLine(w, struct {
+		Type string `json:"type"`
+		jsonFile
+	}{"file", newJSONFile(file)})
+}
+
+func (f *JSONLFormat) WriteFooter(w io.Writer, summary Summary) error {
+	return writeJSONLine(w, struct {
+		Type string `json:"type"`
+		jsonStats
+		Manifest []jsonPart `json:"manifest,omitempty"`
+	}{
+		Type:      "stats",
+		jsonStats: jsonStats{Files: summary.Files, TotalSize: summary.TotalSize, TotalTokens: summary.TotalTokens},
+		Manifest:  newJSONManifest(summary),
+	})
+}
+
+// WriteTrees writes a single "tree" record with the "tree" or "roots" field.
+func (f *JSONLFormat) WriteTrees(w io.Writer, trees []Tree) error {
+	return writeJSONLine(w, struct {
+		Type string `json:"type"`
+		jsonTrees
+	}{"tree", newJSONTrees(trees)})
+}
+
+func writeJSONLine(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
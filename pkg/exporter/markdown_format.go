@@ -0,0 +1,126 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/fileutils"
+)
+
+// MarkdownFormat writes a Markdown document with one fenced block per file.
+type MarkdownFormat struct{}
+
+func (f *MarkdownFormat) Extension() string {
+	return ".md"
+}
+
+func (f *MarkdownFormat) WriteHeader(w io.Writer, header Header) error {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("# %s\n\n", header.Title))
+	sb.WriteString(exportDescription + "\n\n")
+	for _, criterion := range header.Criteria {
+		sb.WriteString(fmt.Sprintf("* %s\n", criterion))
+	}
+	// The dropped files belong to the budget criterion, which comes last.
+	for _, d := range header.Dropped {
+		sb.WriteString(fmt.Sprintf("  * %s (%d tokens): %s\n", d.Path, d.TokenCount, d.Reason))
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString("## Repository Structure\n\n")
+	for i, tree := range header.Trees {
+		if tree.Label != "" {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(fmt.Sprintf("### %s\n\n", tree.Label))
+		}
+		sb.WriteString(tree.Structure)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteTrees writes the structures as plain text, one after the other.
+func (f *MarkdownFormat) WriteTrees(w io.Writer, trees []Tree) error {
+	for _, tree := range trees {
+		if _, err := io.WriteString(w, tree.Structure); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *MarkdownFormat) WriteFile(w io.Writer, file FileInfo, index int) error {
+	label := file.Path
+	if notes := fileNotes(file); len(notes) > 0 {
+		label = fmt.Sprintf("%s (%s)", file.Path, strings.Join(notes, ", "))
+	}
+	if _, err := fmt.Fprintf(w, "// File: %s\n", label); err != nil {
+		return err
+	}
+
+	var err error
+	if file.Excluded {
+		_, err = fmt.Fprintf(w, "%s\n\n", file.Content)
+	} else {
+		fence := markdownFence(file.Content)
+		_, err = fmt.Fprintf(w, "%s%s\n%s\n%s\n\n", fence, fenceLanguage(file), file.Content, fence)
+	}
+	if err != nil || len(file.Diff) == 0 {
+		return err
+	}
+
+	fence := markdownFence(file.Diff)
+	_, err = fmt.Fprintf(w, "// Diff: %s\n%sdiff\n%s\n%s\n\n", file.Path, fence, file.Diff, fence)
+	return err
+}
+
+// fenceLanguage prefers the extension's fence language, which can be more
+// specific (tsx rather than typescript), unless the detected language
+// disagrees with the extension, as for a C++ .h file or a shell script
+// without one.
+func fenceLanguage(file FileInfo) string {
+	if file.Language != "" && !fileutils.IsLanguageFile(file.Language, fileutils.GetFileExtension(file.Path)) {
+		return fileutils.LanguageFence(file.Language)
+	}
+	return fileutils.FenceLanguage(file.Path)
+}
+
+// markdownFence returns a backtick fence longer than any backtick run in
+// content, so content containing fences of its own cannot close the block.
+func markdownFence(content []byte) string {
+	longest, run := 0, 0
+	for _, b := range content {
+		if b == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+func (f *MarkdownFormat) WriteFooter(w io.Writer, summary Summary) error {
+	if summary.Chunks == nil {
+		return nil
+	}
+
+	var sb strings.Builder
+	sb.WriteString("## Manifest\n\n")
+	sb.WriteString(fmt.Sprintf("This export is split into %d parts:\n\n", len(summary.Chunks)))
+	for i, chunk := range summary.Chunks {
+		marker := ""
+		if i == summary.Part {
+			marker = " (this part)"
+		}
+		sb.WriteString(fmt.Sprintf("* Part %d%s: %s\n", i+1, marker, strings.Join(chunkNames(chunk), ", ")))
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
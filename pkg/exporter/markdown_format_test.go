@@ -0,0 +1,53 @@
+// File: pkg/exporter/markdown_format_test.go
+
+package exporter
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkdownFormatWriteFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     FileInfo
+		expected string
+	}{
+		{
+			name:     "Plain file",
+			file:     FileInfo{Path: "main.go", Content: []byte("package main")},
+			expected: "// File: main.go\n```go\npackage main\n```\n\n",
+		},
+		{
+			name:     "Markdown with a fence",
+			file:     FileInfo{Path: "README.md", Content: []byte("# Title\n\n```go\nx := 1\n```")},
+			expected: "// File: README.md\n````markdown\n# Title\n\n```go\nx := 1\n```\n````\n\n",
+		},
+		{
+			name:     "Longer backtick run",
+			file:     FileInfo{Path: "doc.md", Content: []byte("`````")},
+			expected: "// File: doc.md\n``````markdown\n`````\n``````\n\n",
+		},
+		{
+			name:     "Excluded file",
+			file:     FileInfo{Path: "big.go", Content: []byte("// File excluded due to size: 5000 tokens"), Excluded: true},
+			expected: "// File: big.go\n// File excluded due to size: 5000 tokens\n\n",
+		},
+		{
+			name:     "Segment",
+			file:     FileInfo{Path: "big.js", Content: []byte("let x = 1;"), Segment: "lines 1-1 of 2"},
+			expected: "// File: big.js (lines 1-1 of 2)\n```javascript\nlet x = 1;\n```\n\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, (&MarkdownFormat{}).WriteFile(&buf, tt.file, 1))
+			assert.Equal(t, tt.expected, buf.String())
+		})
+	}
+}
@@ -0,0 +1,143 @@
+// File: pkg/exporter/options.go
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+
+	"github.com/daemonp/gogpt/pkg/types"
+	"github.com/rs/zerolog"
+)
+
+// Options configures an export made with Export. The zero value exports the
+// files of the detected languages as Markdown, without limits.
+type Options struct {
+	// FS is the filesystem to export, e.g. os.DirFS(dir), an embed.FS or
+	// an fstest.MapFS.
+	FS fs.FS
+	// Name labels the root of the repository structure (default: ".").
+	Name string
+	// GitIgnore leaves out the paths ignored by the .gitignore files in FS.
+	GitIgnore bool
+	// Languages selects the languages to export; they are detected when
+	// empty.
+	Languages []string
+	// Include and Exclude are gitignore-style globs of the paths to export
+	// and to leave out.
+	Include []string
+	Exclude []string
+	// ExcludePattern is a regular expression of lines to filter out.
+	ExcludePattern string
+
+	IncludeGenerated   bool
+	IncludeVendored    bool
+	StripComments      bool
+	KeepDocComments    bool
+	CollapseBlankLines bool
+	Signatures         bool
+
+	// MaxTokens limits the tokens of each file, with larger files handled
+	// as Oversize says: exclude, head, head-tail or outline. Zero means no
+	// limit.
+	MaxTokens int
+	Oversize  string
+	// Budget limits the tokens of the whole export, packing files as Pack
+	// says, size or depth, with the files matching Priority first. Zero
+	// means no limit.
+	Budget   int
+	Pack     string
+	Priority []string
+
+	// Order is the order of the files: path, size, tokens, mtime or
+	// dependency.
+	Order        string
+	TreeAnnotate bool
+	TreeOnly     bool
+	TreeFiltered bool
+	// Format is markdown, xml, json or jsonl, and Encoding the tokenizer,
+	// cl100k_base or o200k_base.
+	Format   string
+	Encoding string
+
+	// Jobs is the number of files processed at once (default: the number
+	// of CPUs).
+	Jobs        int
+	ReadTimeout time.Duration
+	// Strict fails the export, without writing it, when any file cannot be
+	// read.
+	Strict bool
+
+	// SpoolDir is where the contents of large exports are held until they
+	// are written (default: os.TempDir()). Smaller exports stay in memory.
+	SpoolDir string
+	// Logger receives the progress and warnings of the export. Nothing is
+	// logged when it is nil.
+	Logger *zerolog.Logger
+}
+
+// Export writes an export of opts.FS to w. It does not write to the
+// process's standard output or change opts, and only logs to opts.Logger. Files that cannot be read are
+// reported with a *ScanError, as by Exporter.Export.
+func Export(ctx context.Context, opts Options, w io.Writer) error {
+	if opts.FS == nil {
+		return fmt.Errorf("no filesystem to export")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = "."
+	}
+	logger := zerolog.Nop()
+	if opts.Logger != nil {
+		logger = *opts.Logger
+	}
+	e, err := newExporter(ctx, []*root{openFSRoot(opts.FS, name, opts.GitIgnore, logger)}, opts.flags(), w, logger)
+	if err != nil {
+		return err
+	}
+	e.spoolDir = opts.SpoolDir
+	return e.Export(ctx)
+}
+
+// flags returns the flags equivalent to the options.
+func (o Options) flags() *types.Flags {
+	flags := &types.Flags{
+		UseGitIgnore:       o.GitIgnore,
+		Languages:          strings.Join(o.Languages, ","),
+		IncludePaths:       o.Include,
+		ExcludePaths:       o.Exclude,
+		ExcludePattern:     o.ExcludePattern,
+		IncludeGenerated:   o.IncludeGenerated,
+		IncludeVendored:    o.IncludeVendored,
+		StripComments:      o.StripComments,
+		KeepDocComments:    o.KeepDocComments,
+		CollapseBlankLines: o.CollapseBlankLines,
+		Signatures:         o.Signatures,
+		Oversize:           o.Oversize,
+		PackStrategy:       o.Pack,
+		PriorityGlobs:      o.Priority,
+		Order:              o.Order,
+		TreeAnnotate:       o.TreeAnnotate,
+		TreeOnly:           o.TreeOnly,
+		TreeFiltered:       o.TreeFiltered,
+		Format:             o.Format,
+		Encoding:           o.Encoding,
+		ReadTimeout:        o.ReadTimeout,
+		Strict:             o.Strict,
+	}
+	if o.MaxTokens > 0 {
+		flags.MaxTokens = &o.MaxTokens
+	}
+	if o.Budget > 0 {
+		flags.Budget = &o.Budget
+	}
+	if o.Jobs > 0 {
+		flags.Jobs = &o.Jobs
+	}
+	return flags
+}
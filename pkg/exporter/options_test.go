@@ -0,0 +1,157 @@
+// File: pkg/exporter/options_test.go
+
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportOptions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":         {Data: []byte("package main\n\nfunc main() {}\n")},
+		"lib/util.go":     {Data: []byte("package lib\n")},
+		"lib/util.py":     {Data: []byte("print('util')\n")},
+		"vendor/x/x.go":   {Data: []byte("package x\n")},
+		"docs/index.html": {Data: []byte("<html></html>\n")},
+		"gen/.gitignore":  {Data: []byte("*.go\n")},
+		"gen/out.go":      {Data: []byte("package gen\n")},
+	}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "Detected languages",
+			opts:     Options{FS: fsys},
+			contains: []string{"// File: main.go", "// File: lib/util.go", "// File: lib/util.py", "// File: docs/index.html"},
+			excludes: []string{"vendor/x/x.go"},
+		},
+		{
+			name:     "Gitignore",
+			opts:     Options{FS: fsys, Languages: []string{"go"}, GitIgnore: true},
+			contains: []string{"// File: main.go", "// File: lib/util.go"},
+			excludes: []string{"// File: gen/out.go"},
+		},
+		{
+			name:     "Selected languages",
+			opts:     Options{FS: fsys, Languages: []string{"go"}, Exclude: []string{"lib/**"}},
+			contains: []string{"// File: main.go"},
+			excludes: []string{"lib/util.go", "lib/util.py"},
+		},
+		{
+			name:     "Tree only",
+			opts:     Options{FS: fsys, Name: "repo", Languages: []string{"go"}, TreeOnly: true},
+			contains: []string{"repo\n├── gen\n│   └── out.go\n├── lib\n│   └── util.go\n└── main.go\n"},
+			excludes: []string{"// File:"},
+		},
+		{
+			name:     "Format",
+			opts:     Options{FS: fsys, Languages: []string{"go"}, Format: "xml"},
+			contains: []string{`path="main.go"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, Export(context.Background(), tt.opts, &buf))
+
+			for _, s := range tt.contains {
+				assert.Contains(t, buf.String(), s)
+			}
+			for _, s := range tt.excludes {
+				assert.NotContains(t, buf.String(), s)
+			}
+		})
+	}
+}
+
+func TestExportOptionsRequiresFS(t *testing.T) {
+	assert.Error(t, Export(context.Background(), Options{}, &bytes.Buffer{}))
+}
+
+// vendorFailFS fails to open anything in vendor/, to show it is not walked.
+type vendorFailFS struct {
+	fstest.MapFS
+}
+
+func (f vendorFailFS) Open(name string) (fs.File, error) {
+	if name == "vendor" || strings.HasPrefix(name, "vendor/") {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.Open(name)
+}
+
+func (f vendorFailFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "vendor" || strings.HasPrefix(name, "vendor/") {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrPermission}
+	}
+	return f.MapFS.ReadDir(name)
+}
+
+func TestExportSkipsVendoredDirs(t *testing.T) {
+	fsys := vendorFailFS{fstest.MapFS{
+		"main.go":       {Data: []byte("package main\n")},
+		"vendor/x/x.go": {Data: []byte("package x\n")},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), Options{FS: fsys, Languages: []string{"go"}}, &buf))
+	assert.Contains(t, buf.String(), "// File: main.go")
+	assert.Contains(t, buf.String(), "vendor/ (vendored)")
+}
+
+func TestExportDetectsLanguagesOfExportedFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":                 {Data: []byte("package main\n")},
+		"web/app.min.js":          {Data: []byte(strings.Repeat("x();", 100))},
+		"web/node_modules/x/x.js": {Data: []byte(strings.Repeat("module.exports = {};\n", 100))},
+		"go.sum":                  {Data: []byte(strings.Repeat("example.com/x v1.0.0 h1:abc=\n", 100))},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), Options{FS: fsys}, &buf))
+	assert.Contains(t, buf.String(), "by share of the repository: go (100.0%).")
+}
+
+func TestExportOptionsSideEffects(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go": {Data: []byte("package main\n")},
+	}
+
+	// Neither the temporary directory nor the global logger are used.
+	tempDir := t.TempDir()
+	t.Setenv("TMPDIR", tempDir)
+	var global bytes.Buffer
+	oldLogger := log.Logger
+	log.Logger = zerolog.New(&global)
+	defer func() { log.Logger = oldLogger }()
+
+	var buf bytes.Buffer
+	require.NoError(t, Export(context.Background(), Options{FS: fsys}, &buf))
+	assert.Contains(t, buf.String(), "// File: main.go")
+	assert.Empty(t, global.String())
+	entries, err := os.ReadDir(tempDir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	var logs bytes.Buffer
+	logger := zerolog.New(&logs)
+	require.NoError(t, Export(context.Background(), Options{FS: fsys, Logger: &logger}, &bytes.Buffer{}))
+	assert.Contains(t, logs.String(), "Export completed")
+	assert.Empty(t, global.String())
+}
@@ -0,0 +1,261 @@
+// File: pkg/exporter/order.go
+
+package exporter
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Orders of the files in the export. Ties are broken by path.
+const (
+	// OrderPath sorts files lexically by path.
+	OrderPath = "path"
+	// OrderSize sorts files by size, smallest first.
+	OrderSize = "size"
+	// OrderTokens sorts files by token count, smallest first.
+	OrderTokens = "tokens"
+	// OrderMtime sorts files by modification time, newest first.
+	OrderMtime = "mtime"
+	// OrderDependency puts files after the files they import.
+	OrderDependency = "dependency"
+)
+
+func validateOrder(order string) error {
+	switch order {
+	case "", OrderPath, OrderSize, OrderTokens, OrderMtime, OrderDependency:
+		return nil
+	default:
+		return fmt.Errorf("unknown order %q (supported: %s, %s, %s, %s, %s)", order, OrderPath, OrderSize, OrderTokens, OrderMtime, OrderDependency)
+	}
+}
+
+func orderCriterion(order string) string {
+	switch order {
+	case OrderSize:
+		return "Files are ordered by size, smallest first."
+	case OrderTokens:
+		return "Files are ordered by token count, smallest first."
+	case OrderMtime:
+		return "Files are ordered by modification time, newest first."
+	case OrderDependency:
+		return "Files are ordered so that each comes after the files it imports."
+	default:
+		return ""
+	}
+}
+
+// sortByPath sorts files lexically by path, comparing paths with forward
+// slashes so the order is the same on every platform.
+func sortByPath(files []FileInfo) {
+	sort.SliceStable(files, func(i, j int) bool {
+		return filepath.ToSlash(files[i].Path) < filepath.ToSlash(files[j].Path)
+	})
+}
+
+// orderFiles sorts files, which must be sorted by path, into the given order.
+// For the dependency order, content returns the content of a file and
+// readFile reads files outside the export, such as go.mod.
+func orderFiles(files []FileInfo, order string, content func(FileInfo) []byte, readFile func(path string) ([]byte, error)) {
+	switch order {
+	case OrderSize:
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].contentSize() < files[j].contentSize()
+		})
+	case OrderTokens:
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].TokenCount < files[j].TokenCount
+		})
+	case OrderMtime:
+		sort.SliceStable(files, func(i, j int) bool {
+			return files[i].ModTime.After(files[j].ModTime)
+		})
+	case OrderDependency:
+		sorted := dependencyOrder(files, content, readFile)
+		copy(files, sorted)
+	}
+}
+
+// dependencyOrder returns files ordered so that each file comes after the
+// files it imports, visiting files and their imports in path order. Import
+// cycles are broken at the file visited first.
+func dependencyOrder(files []FileInfo, content func(FileInfo) []byte, readFile func(path string) ([]byte, error)) []FileInfo {
+	resolver := &importResolver{
+		paths: make([]string, len(files)),
+		index: make(map[string]int, len(files)),
+		dirs:  make(map[string][]int),
+	}
+	for i, file := range files {
+		slashPath := filepath.ToSlash(file.Path)
+		resolver.paths[i] = slashPath
+		resolver.index[slashPath] = i
+		dir := path.Dir(slashPath)
+		resolver.dirs[dir] = append(resolver.dirs[dir], i)
+	}
+	if goMod, err := readFile("go.mod"); err == nil {
+		if match := goModule.FindSubmatch(goMod); match != nil {
+			resolver.module = string(match[1])
+		}
+	}
+
+	sorted := make([]FileInfo, 0, len(files))
+	visited := make([]bool, len(files))
+	var visit func(i int)
+	visit = func(i int) {
+		if visited[i] {
+			return
+		}
+		visited[i] = true
+		for _, dep := range resolver.imports(files[i], content(files[i])) {
+			if dep != i {
+				visit(dep)
+			}
+		}
+		sorted = append(sorted, files[i])
+	}
+	for i := range files {
+		visit(i)
+	}
+	return sorted
+}
+
+var (
+	goModule      = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)"?`)
+	jsImport      = regexp.MustCompile(`(?:\bfrom\s*|\bimport\s*\(?\s*|\brequire\(\s*)['"](\.{1,2}/[^'"]*|\.{1,2})['"]`)
+	pythonFrom    = regexp.MustCompile(`(?m)^\s*from\s+(\.*)([\w.]*)\s+import\s+\(?([\w\s,.*]+)`)
+	pythonImports = regexp.MustCompile(`(?m)^\s*import\s+([\w.]+(?:\s+as\s+\w+)?(?:\s*,\s*[\w.]+(?:\s+as\s+\w+)?)*)`)
+)
+
+// jsExtensions are tried in order when resolving an extensionless import.
+var jsExtensions = []string{".ts", ".tsx", ".js", ".jsx", ".mjs", ".cjs"}
+
+// importResolver finds the files of an export imported by a file. Only
+// imports within the export are resolved: packages of the Go module, and
+// relative and root-level imports in JavaScript, TypeScript and Python.
+type importResolver struct {
+	// paths are the slash-separated paths of the files.
+	paths []string
+	// index maps paths to files.
+	index map[string]int
+	// dirs maps slash-separated directories to their files.
+	dirs map[string][]int
+	// module is the Go module path from go.mod, if any.
+	module string
+}
+
+// imports returns the indices of the files imported by a file with the
+// given content, sorted.
+func (r *importResolver) imports(file FileInfo, content []byte) []int {
+	filePath := filepath.ToSlash(file.Path)
+	dir := path.Dir(filePath)
+
+	var deps []int
+	switch file.Language {
+	case "go":
+		deps = r.goImports(content)
+	case "js", "ts":
+		for _, match := range jsImport.FindAllSubmatch(content, -1) {
+			deps = append(deps, r.jsFile(path.Join(dir, string(match[1])))...)
+		}
+	case "python":
+		deps = r.pythonImports(dir, content)
+	}
+
+	sort.Ints(deps)
+	return deps
+}
+
+func (r *importResolver) goImports(content []byte) []int {
+	if r.module == "" {
+		return nil
+	}
+	file, err := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+	if err != nil {
+		return nil
+	}
+
+	var deps []int
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || importPath != r.module && !strings.HasPrefix(importPath, r.module+"/") {
+			continue
+		}
+		dir := strings.TrimPrefix(strings.TrimPrefix(importPath, r.module), "/")
+		if dir == "" {
+			dir = "."
+		}
+		for _, i := range r.dirs[dir] {
+			if !strings.HasSuffix(r.paths[i], "_test.go") {
+				deps = append(deps, i)
+			}
+		}
+	}
+	return deps
+}
+
+func (r *importResolver) jsFile(target string) []int {
+	candidates := []string{target}
+	for _, ext := range jsExtensions {
+		candidates = append(candidates, target+ext)
+	}
+	for _, ext := range jsExtensions {
+		candidates = append(candidates, path.Join(target, "index"+ext))
+	}
+	// TypeScript sources import each other with the .js extension of their
+	// compiled output.
+	if ext := path.Ext(target); ext == ".js" || ext == ".jsx" {
+		base := strings.TrimSuffix(target, ext)
+		candidates = append(candidates, base+".ts", base+".tsx")
+	}
+	return r.first(candidates)
+}
+
+func (r *importResolver) pythonImports(dir string, content []byte) []int {
+	var deps []int
+	for _, match := range pythonFrom.FindAllSubmatch(content, -1) {
+		base := "."
+		if dots := len(match[1]); dots > 0 {
+			base = dir
+			for i := 1; i < dots; i++ {
+				base = path.Dir(base)
+			}
+		}
+		module := path.Join(base, strings.ReplaceAll(string(match[2]), ".", "/"))
+		deps = append(deps, r.pythonModule(module)...)
+		// The imported names may be submodules.
+		for _, name := range strings.Split(string(match[3]), ",") {
+			if fields := strings.Fields(name); len(fields) > 0 && fields[0] != "*" {
+				deps = append(deps, r.pythonModule(path.Join(module, fields[0]))...)
+			}
+		}
+	}
+	for _, match := range pythonImports.FindAllSubmatch(content, -1) {
+		for _, name := range strings.Split(string(match[1]), ",") {
+			if fields := strings.Fields(name); len(fields) > 0 {
+				deps = append(deps, r.pythonModule(strings.ReplaceAll(fields[0], ".", "/"))...)
+			}
+		}
+	}
+	return deps
+}
+
+func (r *importResolver) pythonModule(module string) []int {
+	return r.first([]string{module + ".py", path.Join(module, "__init__.py")})
+}
+
+// first returns the first of the candidate paths that is in the export.
+func (r *importResolver) first(candidates []string) []int {
+	for _, candidate := range candidates {
+		if i, ok := r.index[path.Clean(candidate)]; ok {
+			return []int{i}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// File: pkg/exporter/order_test.go
+
+package exporter
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderFiles(t *testing.T) {
+	now := time.Now()
+	file := func(path, content string, tokens int, age time.Duration) FileInfo {
+		return FileInfo{Path: path, Content: []byte(content), TokenCount: tokens, ModTime: now.Add(-age)}
+	}
+
+	tests := []struct {
+		name     string
+		order    string
+		files    []FileInfo
+		expected []string
+	}{
+		{
+			name:  "Path keeps the path order",
+			order: OrderPath,
+			files: []FileInfo{
+				file("a.go", "aaa", 3, time.Hour),
+				file("b.go", "b", 1, 0),
+			},
+			expected: []string{"a.go", "b.go"},
+		},
+		{
+			name:  "Size puts the smallest first, then by path",
+			order: OrderSize,
+			files: []FileInfo{
+				file("a.go", "aaa", 1, 0),
+				file("b.go", "b", 3, 0),
+				file("c.go", "c", 2, 0),
+			},
+			expected: []string{"b.go", "c.go", "a.go"},
+		},
+		{
+			name:  "Tokens puts the fewest first",
+			order: OrderTokens,
+			files: []FileInfo{
+				file("a.go", "a", 3, 0),
+				file("b.go", "b", 1, 0),
+				file("c.go", "c", 2, 0),
+			},
+			expected: []string{"b.go", "c.go", "a.go"},
+		},
+		{
+			name:  "Mtime puts the newest first",
+			order: OrderMtime,
+			files: []FileInfo{
+				file("a.go", "a", 1, time.Hour),
+				file("b.go", "b", 1, 0),
+				file("c.go", "c", 1, time.Minute),
+			},
+			expected: []string{"b.go", "c.go", "a.go"},
+		},
+		{
+			name:  "Dependency puts Go packages before their importers",
+			order: OrderDependency,
+			files: []FileInfo{
+				{Path: "cmd/main.go", Language: "go", Content: []byte("package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/app/pkg/server\"\n)\n")},
+				{Path: "pkg/server/server.go", Language: "go", Content: []byte("package server\n\nimport \"example.com/app/pkg/util\"\n")},
+				{Path: "pkg/server/server_test.go", Language: "go", Content: []byte("package server\n")},
+				{Path: "pkg/util/util.go", Language: "go", Content: []byte("package util\n")},
+			},
+			expected: []string{"pkg/util/util.go", "pkg/server/server.go", "cmd/main.go", "pkg/server/server_test.go"},
+		},
+		{
+			name:  "Dependency resolves relative JS and TS imports",
+			order: OrderDependency,
+			files: []FileInfo{
+				{Path: "src/app.ts", Language: "ts", Content: []byte("import { api } from './lib/api.js';\nconst x = require('./util');\n")},
+				{Path: "src/lib/api.ts", Language: "ts", Content: []byte("import config from '../config';\n")},
+				{Path: "src/config/index.ts", Language: "ts", Content: []byte("export default {};\n")},
+				{Path: "src/util.js", Language: "js", Content: []byte("module.exports = {};\n")},
+			},
+			expected: []string{"src/config/index.ts", "src/lib/api.ts", "src/util.js", "src/app.ts"},
+		},
+		{
+			name:  "Dependency resolves Python imports",
+			order: OrderDependency,
+			files: []FileInfo{
+				{Path: "app/__init__.py", Language: "python", Content: []byte("")},
+				{Path: "app/main.py", Language: "python", Content: []byte("from . import models\nimport app.db\n")},
+				{Path: "app/models.py", Language: "python", Content: []byte("from .db import session\n")},
+				{Path: "app/db.py", Language: "python", Content: []byte("import os\n")},
+			},
+			expected: []string{"app/__init__.py", "app/db.py", "app/models.py", "app/main.py"},
+		},
+		{
+			name:  "Dependency breaks cycles in path order",
+			order: OrderDependency,
+			files: []FileInfo{
+				{Path: "a.py", Language: "python", Content: []byte("import b\n")},
+				{Path: "b.py", Language: "python", Content: []byte("import a\n")},
+			},
+			expected: []string{"b.py", "a.py"},
+		},
+	}
+
+	readFile := func(path string) ([]byte, error) {
+		if path == "go.mod" {
+			return []byte("module example.com/app\n\ngo 1.22\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortByPath(tt.files)
+			orderFiles(tt.files, tt.order, func(file FileInfo) []byte { return file.Content }, readFile)
+
+			paths := make([]string, len(tt.files))
+			for i, file := range tt.files {
+				paths[i] = file.Path
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+
+	assert.NoError(t, validateOrder(""))
+	assert.ErrorContains(t, validateOrder("random"), `unknown order "random"`)
+}
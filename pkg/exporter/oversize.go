@@ -0,0 +1,115 @@
+// File: pkg/exporter/oversize.go
+
+package exporter
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Strategies for files over the token limit.
+const (
+	// OversizeExclude replaces the content with a notice.
+	OversizeExclude = "exclude"
+	// OversizeHead keeps the first tokens up to the limit.
+	OversizeHead = "head"
+	// OversizeHeadTail keeps the first and last halves of the limit.
+	OversizeHeadTail = "head-tail"
+	// OversizeOutline keeps the file's skeleton, falling back to its head
+	// when the language has no skeletonizer or the skeleton is too large.
+	OversizeOutline = "outline"
+)
+
+func validateOversize(strategy string) error {
+	switch strategy {
+	case OversizeExclude, OversizeHead, OversizeHeadTail, OversizeOutline:
+		return nil
+	default:
+		return fmt.Errorf("unknown oversize strategy %q (supported: %s, %s, %s, %s)", strategy, OversizeExclude, OversizeHead, OversizeHeadTail, OversizeOutline)
+	}
+}
+
+func oversizeCriterion(strategy string, limit int) string {
+	switch strategy {
+	case OversizeHead:
+		return fmt.Sprintf("Files exceeding the token limit (%d tokens) are truncated to their first %d tokens.", limit, limit)
+	case OversizeHeadTail:
+		return fmt.Sprintf("Files exceeding the token limit (%d tokens) are truncated to their first %d and last %d tokens.", limit, limit/2, limit-limit/2)
+	case OversizeOutline:
+		return fmt.Sprintf("Files exceeding the token limit (%d tokens) are reduced to an outline of their declarations, or truncated to their first %d tokens when no outline fits.", limit, limit)
+	default:
+		return fmt.Sprintf("Files exceeding the token limit (%d tokens) are noted but not included.", limit)
+	}
+}
+
+// shorten applies the oversize strategy to the content of a file with more
+// than the token limit, returning the new content and whether the file is
+// excluded rather than shortened. The content starts with a notice stating
+// what was kept.
+func (fp *FileProcessor) shorten(path, language string, content []byte, tokenCount int) ([]byte, bool) {
+	limit := *fp.maxTokens
+
+	switch fp.oversize {
+	case OversizeHead:
+		return fp.head(content, tokenCount, limit), false
+	case OversizeHeadTail:
+		tokens := fp.encoding.Encode(string(content))
+		headLen := fp.tokenBytes(tokens[:limit/2])
+		tailLen := fp.tokenBytes(tokens[len(tokens)-(limit-limit/2):])
+		head, tail := runePrefix(content, headLen), runeSuffix(content, tailLen)
+
+		notice := fmt.Sprintf("// File truncated due to size: %d tokens; the first %d and last %d tokens are kept.\n", tokenCount, limit/2, limit-limit/2)
+		elision := fmt.Sprintf("\n// ... %d tokens elided ...\n", len(tokens)-limit)
+		return []byte(notice + string(head) + elision + string(tail)), false
+	case OversizeOutline:
+		if skeletonizer, ok := skeletonizers[language]; ok {
+			skeleton, err := skeletonizer.Skeletonize(content)
+			if err != nil {
+				fp.logger.Warn().Err(err).Str("file", path).Msg("Failed to outline file, keeping its head")
+				return fp.head(content, tokenCount, limit), false
+			}
+			if outlineTokens := fp.encoding.Count(string(skeleton)); outlineTokens <= limit {
+				notice := fmt.Sprintf("// File reduced to an outline due to size: %d tokens; declarations are kept without their bodies.\n", tokenCount)
+				return append([]byte(notice), skeleton...), false
+			}
+		}
+		return fp.head(content, tokenCount, limit), false
+	default:
+		return []byte(fmt.Sprintf("// File excluded due to size: %d tokens", tokenCount)), true
+	}
+}
+
+func (fp *FileProcessor) head(content []byte, tokenCount, limit int) []byte {
+	tokens := fp.encoding.Encode(string(content))
+	head := runePrefix(content, fp.tokenBytes(tokens[:limit]))
+	notice := fmt.Sprintf("// File truncated due to size: %d tokens; the first %d tokens are kept.\n", tokenCount, limit)
+	return append([]byte(notice), head...)
+}
+
+// tokenBytes returns the length in bytes of the text of tokens.
+func (fp *FileProcessor) tokenBytes(tokens []int) int {
+	text, err := fp.encoding.Decode(tokens)
+	if err != nil {
+		return 0
+	}
+	return len(text)
+}
+
+// runePrefix returns the first n bytes of content, shortened so it does not
+// end within a multi-byte character.
+func runePrefix(content []byte, n int) []byte {
+	for n > 0 && n < len(content) && !utf8.RuneStart(content[n]) {
+		n--
+	}
+	return content[:n]
+}
+
+// runeSuffix returns the last n bytes of content, shortened so it does not
+// start within a multi-byte character.
+func runeSuffix(content []byte, n int) []byte {
+	start := len(content) - n
+	for start < len(content) && !utf8.RuneStart(content[start]) {
+		start++
+	}
+	return content[start:]
+}
@@ -0,0 +1,154 @@
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/pathmatch"
+	"github.com/daemonp/gogpt/pkg/tiktoken"
+)
+
+const (
+	PackBySize  = "size"
+	PackByDepth = "depth"
+)
+
+// Packer selects the files that fit into a token budget. Files matching a
+// priority glob are considered first, in the order the globs were given,
+// followed by the rest ordered by the packing strategy.
+type Packer struct {
+	strategy   string
+	priorities []*pathmatch.Matcher
+	encoding   *tiktoken.Encoding
+	format     Format
+}
+
+type DroppedFile struct {
+	Path       string
+	TokenCount int
+	Reason     string
+}
+
+func NewPacker(strategy string, priorities []string, encoding *tiktoken.Encoding, format Format) (*Packer, error) {
+	if strategy == "" {
+		strategy = PackBySize
+	}
+	if strategy != PackBySize && strategy != PackByDepth {
+		return nil, fmt.Errorf("unknown packing strategy %q (supported: %s, %s)", strategy, PackBySize, PackByDepth)
+	}
+
+	matchers := make([]*pathmatch.Matcher, len(priorities))
+	for i, pattern := range priorities {
+		matcher, err := pathmatch.New([]string{pattern})
+		if err != nil {
+			return nil, fmt.Errorf("invalid priority glob: %w", err)
+		}
+		matchers[i] = matcher
+	}
+
+	return &Packer{
+		strategy:   strategy,
+		priorities: matchers,
+		encoding:   encoding,
+		format:     format,
+	}, nil
+}
+
+// Pack greedily fills the budget in priority order, skipping files that no
+// longer fit so smaller files further down can still be included. Kept files
+// are returned in their original order.
+func (p *Packer) Pack(files []FileInfo, budget int) ([]FileInfo, []DroppedFile) {
+	order := make([]int, len(files))
+	costs := make([]int, len(files))
+	ranks := make([]int, len(files))
+	for i, file := range files {
+		order[i] = i
+		costs[i] = p.Cost(file)
+		ranks[i] = p.priorityRank(file.Path)
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		i, j := order[a], order[b]
+		if ranks[i] != ranks[j] {
+			return ranks[i] < ranks[j]
+		}
+		if p.strategy == PackByDepth {
+			if di, dj := pathDepth(files[i].Path), pathDepth(files[j].Path); di != dj {
+				return di < dj
+			}
+		}
+		if costs[i] != costs[j] {
+			return costs[i] < costs[j]
+		}
+		return files[i].Path < files[j].Path
+	})
+
+	keep := make([]bool, len(files))
+	var dropped []DroppedFile
+	remaining := budget
+
+	for _, i := range order {
+		switch {
+		case costs[i] > budget:
+			dropped = append(dropped, DroppedFile{Path: files[i].Path, TokenCount: costs[i], Reason: "larger than the whole budget"})
+		case costs[i] > remaining:
+			dropped = append(dropped, DroppedFile{Path: files[i].Path, TokenCount: costs[i], Reason: "does not fit in the remaining budget"})
+		default:
+			keep[i] = true
+			remaining -= costs[i]
+		}
+	}
+
+	var kept []FileInfo
+	for i, file := range files {
+		if keep[i] {
+			kept = append(kept, file)
+		}
+	}
+
+	return kept, dropped
+}
+
+func (p *Packer) Cost(file FileInfo) int {
+	return fileCost(p.encoding, p.format, file)
+}
+
+func (p *Packer) totalCost(files []FileInfo) int {
+	total := 0
+	for _, file := range files {
+		total += p.Cost(file)
+	}
+	return total
+}
+
+func (p *Packer) priorityRank(filePath string) int {
+	slashPath := filepath.ToSlash(filePath)
+	for i, matcher := range p.priorities {
+		if matcher.Match(slashPath) {
+			return i
+		}
+	}
+	return len(p.priorities)
+}
+
+// fileCost estimates the tokens a file adds to the export: its content plus
+// whatever the format writes around it, including any diff.
+func fileCost(encoding *tiktoken.Encoding, format Format, file FileInfo) int {
+	wrapped := file
+	wrapped.Content = nil
+
+	var wrapper bytes.Buffer
+	_ = format.WriteFile(&wrapper, wrapped, 2)
+	overhead := encoding.Count(wrapper.String())
+	if file.Excluded {
+		return overhead + encoding.Count(string(file.Content))
+	}
+	return overhead + file.TokenCount
+}
+
+func pathDepth(filePath string) int {
+	return strings.Count(filepath.ToSlash(filePath), "/")
+}
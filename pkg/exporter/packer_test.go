@@ -0,0 +1,95 @@
+// File: pkg/exporter/packer_test.go
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daemonp/gogpt/pkg/tiktoken"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPack(t *testing.T) {
+	encoding, err := tiktoken.GetEncoding(tiktoken.CL100kBase)
+	require.NoError(t, err)
+
+	file := func(path string, tokens int) FileInfo {
+		return FileInfo{Path: path, Content: []byte(strings.Repeat(" x", tokens)), TokenCount: tokens}
+	}
+	files := []FileInfo{
+		file("a/b/deep.go", 10),
+		file("big.go", 500),
+		file("main.go", 40),
+		file("docs/guide.md", 30),
+	}
+
+	tests := []struct {
+		name        string
+		strategy    string
+		priorities  []string
+		budget      int
+		expected    []string
+		droppedPath []string
+	}{
+		{
+			name:        "Size packs smallest first",
+			strategy:    PackBySize,
+			budget:      80,
+			expected:    []string{"a/b/deep.go", "docs/guide.md"},
+			droppedPath: []string{"main.go", "big.go"},
+		},
+		{
+			name:        "Depth packs shallowest first",
+			strategy:    PackByDepth,
+			budget:      80,
+			expected:    []string{"a/b/deep.go", "main.go"},
+			droppedPath: []string{"big.go", "docs/guide.md"},
+		},
+		{
+			name:        "Priority globs come first",
+			strategy:    PackBySize,
+			priorities:  []string{"*.md", "main.go"},
+			budget:      100,
+			expected:    []string{"main.go", "docs/guide.md"},
+			droppedPath: []string{"a/b/deep.go", "big.go"},
+		},
+		{
+			name:     "Everything fits",
+			strategy: PackBySize,
+			budget:   1000,
+			expected: []string{"a/b/deep.go", "big.go", "main.go", "docs/guide.md"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			packer, err := NewPacker(tt.strategy, tt.priorities, encoding, &MarkdownFormat{})
+			require.NoError(t, err)
+
+			kept, dropped := packer.Pack(files, tt.budget)
+
+			var keptPaths []string
+			for _, f := range kept {
+				keptPaths = append(keptPaths, f.Path)
+			}
+			var droppedPaths []string
+			for _, d := range dropped {
+				droppedPaths = append(droppedPaths, d.Path)
+			}
+
+			assert.Equal(t, tt.expected, keptPaths)
+			assert.Equal(t, tt.droppedPath, droppedPaths)
+			assert.LessOrEqual(t, packer.totalCost(kept), tt.budget)
+		})
+	}
+}
+
+func TestNewPackerInvalid(t *testing.T) {
+	_, err := NewPacker("random", nil, nil, nil)
+	assert.Error(t, err)
+
+	_, err = NewPacker(PackBySize, []string{"[unterminated"}, nil, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,127 @@
+// File: pkg/exporter/pipeline.go
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// scanRoot runs the scanning stages of the export pipeline for one root:
+// the file processor lists, reads, filters and counts the files, workers
+// apply the content reductions and the exclude pattern, and each file's
+// content is moved to the spool. The files are returned sorted by path,
+// holding only their metadata.
+func (e *Exporter) scanRoot(ctx context.Context, r *root) ([]FileInfo, error) {
+	scanned := make(chan FileInfo, streamBuffer)
+	prepared := make(chan FileInfo, streamBuffer)
+
+	scanDone := make(chan error, 1)
+	go func() {
+		scanDone <- r.fileProcessor.Stream(ctx, scanned)
+	}()
+
+	// The workers drain the scan without preparing files once ctx is
+	// cancelled, so every stage ends.
+	var wg sync.WaitGroup
+	for i := 0; i < e.jobs(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range scanned {
+				if ctx.Err() != nil {
+					continue
+				}
+				e.prepare(&file)
+				prepared <- file
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(prepared)
+	}()
+
+	var files []FileInfo
+	var spoolErr error
+	for file := range prepared {
+		// The stages before keep running until the scan ends, so a failure
+		// to spool only stops spooling.
+		if spoolErr == nil {
+			spoolErr = e.spool.store(&file)
+		}
+		e.tokensSaved += file.TokensSaved
+		files = append(files, file)
+	}
+
+	if err := <-scanDone; err != nil {
+		return nil, fmt.Errorf("failed to scan files: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if spoolErr != nil {
+		return nil, spoolErr
+	}
+
+	sortByPath(files)
+	return files, nil
+}
+
+// jobs returns the number of files to process at once.
+func (e *Exporter) jobs() int {
+	if e.flags.Jobs != nil {
+		return *e.flags.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// prepare applies the content reductions and the exclude pattern to a file.
+func (e *Exporter) prepare(file *FileInfo) {
+	if file.Excluded {
+		return
+	}
+	if e.reducing() {
+		e.reduce(file)
+	}
+	if e.contentFilter.excludePattern != nil {
+		file.Content = e.contentFilter.Filter(file.Content)
+		file.TokenCount = e.encoding.Count(string(file.Content))
+	}
+}
+
+// writeFiles is the last stage of the pipeline: it reads each file back from
+// the spool, writes it and releases its content before the next.
+func (e *Exporter) writeFiles(ctx context.Context, writer *Writer, files []FileInfo) error {
+	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		loaded, err := e.spool.load(file)
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteFile(loaded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadLarge reads back from the spool the files too large for a chunk of
+// limit tokens, which the chunker splits by their lines.
+func (e *Exporter) loadLarge(files []FileInfo, limit int) error {
+	for i, file := range files {
+		if file.spooled == nil || fileCost(e.encoding, e.format, file) <= limit {
+			continue
+		}
+		loaded, err := e.spool.load(file)
+		if err != nil {
+			return err
+		}
+		files[i] = loaded
+	}
+	return nil
+}
@@ -0,0 +1,247 @@
+// File: pkg/exporter/python_skeleton.go
+
+package exporter
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/minifier"
+)
+
+// pythonLine is a logical line of Python: a statement that may span several
+// physical lines inside brackets, strings or after a backslash.
+type pythonLine struct {
+	text   []byte
+	indent int
+	// code marks the bytes of text that are code, as minifier.Code does.
+	code []bool
+	// literal is true when the line is only a string, like a docstring.
+	literal bool
+	// comment is true when the line is only a comment.
+	comment bool
+}
+
+var (
+	pythonFunction   = regexp.MustCompile(`^(async\s+)?def\s`)
+	pythonClass      = regexp.MustCompile(`^class\s`)
+	pythonImport     = regexp.MustCompile(`^(import|from)\s`)
+	pythonAssignment = regexp.MustCompile(`^([A-Za-z_]\w*)[\w.]*\s*(:[^=]|=[^=])`)
+)
+
+// pythonKeywords start compound statements that look like annotations.
+var pythonKeywords = map[string]bool{
+	"if": true, "elif": true, "else": true, "try": true, "except": true, "finally": true,
+	"for": true, "while": true, "with": true, "match": true, "case": true, "lambda": true,
+}
+
+func isPythonAssignment(statement []byte) bool {
+	match := pythonAssignment.FindSubmatch(statement)
+	return match != nil && !pythonKeywords[string(match[1])]
+}
+
+// pythonSkeleton keeps imports, assignments, decorators, class and function
+// definitions and docstrings, replacing function bodies with "...". Other
+// statements are dropped along with their blocks.
+func pythonSkeleton(content []byte) ([]byte, error) {
+	lines := pythonLines(content)
+
+	var out bytes.Buffer
+	pythonBlock(&out, lines, 0, -1)
+	return out.Bytes(), nil
+}
+
+// pythonBlock writes the skeleton of the lines from start that are indented
+// more than parent, and returns the index of the line after them and the
+// number of lines written.
+func pythonBlock(out *bytes.Buffer, lines []pythonLine, start, parent int) (int, int) {
+	written := 0
+	decorated := false
+	separate := func() {
+		if out.Len() > 0 && !decorated {
+			out.WriteByte('\n')
+		}
+	}
+
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		if line.comment || len(bytes.TrimSpace(line.text)) == 0 {
+			i++
+			continue
+		}
+		if line.indent <= parent {
+			break
+		}
+
+		statement := bytes.TrimSpace(line.text)
+		switch {
+		case statement[0] == '@':
+			separate()
+			writeLine(out, line.text)
+			decorated = true
+			i++
+		case pythonFunction.Match(statement) && pythonInlineBody(line) >= 0:
+			separate()
+			writeInlineHeader(out, line)
+			i = pythonSkip(lines, i+1, line.indent)
+		case pythonFunction.Match(statement):
+			separate()
+			writeLine(out, line.text)
+			i++
+			if i < len(lines) && lines[i].literal && lines[i].indent > line.indent {
+				writeLine(out, lines[i].text)
+				i++
+			}
+			out.WriteString(strings.Repeat(" ", pythonBodyIndent(lines, i, line.indent)) + "...\n")
+			i = pythonSkip(lines, i, line.indent)
+		case pythonClass.Match(statement) && pythonInlineBody(line) >= 0:
+			separate()
+			writeInlineHeader(out, line)
+			i = pythonSkip(lines, i+1, line.indent)
+		case pythonClass.Match(statement):
+			separate()
+			writeLine(out, line.text)
+			next, body := pythonBlock(out, lines, i+1, line.indent)
+			if body == 0 {
+				out.WriteString(strings.Repeat(" ", pythonBodyIndent(lines, i+1, line.indent)) + "...\n")
+			}
+			i = next
+		case line.literal, pythonImport.Match(statement), isPythonAssignment(statement):
+			writeLine(out, line.text)
+			i = pythonSkip(lines, i+1, line.indent)
+		default:
+			i = pythonSkip(lines, i+1, line.indent)
+			continue
+		}
+
+		if statement[0] != '@' {
+			decorated = false
+		}
+		written++
+	}
+	return i, written
+}
+
+// writeLine writes a line, ending it with a newline if it has none.
+func writeLine(out *bytes.Buffer, line []byte) {
+	out.Write(line)
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		out.WriteByte('\n')
+	}
+}
+
+// pythonInlineBody returns the position in a compound statement of the
+// colon its body follows on the same line, as in "def f(): return 1", or -1
+// if the body is on the lines after it.
+func pythonInlineBody(line pythonLine) int {
+	depth := 0
+	for i, b := range line.text {
+		if !line.code[i] {
+			continue
+		}
+		switch b {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth = max(depth-1, 0)
+		case ':':
+			if depth > 0 {
+				continue
+			}
+			// Anything but a comment after the colon is the body.
+			rest := bytes.TrimSpace(line.text[i+1:])
+			if len(rest) > 0 && rest[0] != '#' {
+				return i
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// writeInlineHeader writes a compound statement with its body on the same
+// line, replacing the body with "...".
+func writeInlineHeader(out *bytes.Buffer, line pythonLine) {
+	out.Write(line.text[:pythonInlineBody(line)+1])
+	out.WriteString(" ...\n")
+}
+
+// pythonSkip returns the index of the first line from start that is not
+// part of the block of a line indented by indent.
+func pythonSkip(lines []pythonLine, start, indent int) int {
+	i := start
+	for i < len(lines) && (lines[i].indent > indent || lines[i].comment || len(bytes.TrimSpace(lines[i].text)) == 0) {
+		i++
+	}
+	return i
+}
+
+// pythonBodyIndent returns the indentation of the block of a line indented
+// by indent, or four more spaces if the block is empty.
+func pythonBodyIndent(lines []pythonLine, start, indent int) int {
+	for _, line := range lines[start:] {
+		if !line.comment && len(bytes.TrimSpace(line.text)) > 0 {
+			if line.indent > indent {
+				return line.indent
+			}
+			break
+		}
+	}
+	return indent + 4
+}
+
+// pythonLines splits Python source into logical lines.
+func pythonLines(content []byte) []pythonLine {
+	code := minifier.Code("python", content)
+
+	var lines []pythonLine
+	start, depth := 0, 0
+	for i := 0; i <= len(content); i++ {
+		if i < len(content) {
+			if !code[i] {
+				continue
+			}
+			switch content[i] {
+			case '(', '[', '{':
+				depth++
+				continue
+			case ')', ']', '}':
+				depth = max(depth-1, 0)
+				continue
+			case '\n':
+				if depth > 0 || i > 0 && content[i-1] == '\\' {
+					continue
+				}
+			default:
+				continue
+			}
+		}
+
+		end := min(i+1, len(content))
+		if start == end {
+			break
+		}
+		text := content[start:end]
+		statement := bytes.TrimLeft(text, " \t")
+		line := pythonLine{text: text, indent: len(text) - len(statement), code: code[start:end]}
+
+		offset := start + line.indent
+		trimmed := bytes.TrimSpace(statement)
+		if len(trimmed) > 0 {
+			line.comment = trimmed[0] == '#'
+			line.literal = !line.comment
+			for j := offset; j < offset+len(trimmed); j++ {
+				if code[j] && content[j] != ' ' && content[j] != '\t' {
+					line.literal = false
+					break
+				}
+			}
+		}
+
+		lines = append(lines, line)
+		start = end
+	}
+	return lines
+}
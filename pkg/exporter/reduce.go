@@ -0,0 +1,78 @@
+// File: pkg/exporter/reduce.go
+
+package exporter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/minifier"
+)
+
+// reducing reports whether any content reduction is selected.
+func (e *Exporter) reducing() bool {
+	return e.flags.Signatures || e.minifyOptions().Enabled()
+}
+
+func (e *Exporter) minifyOptions() minifier.Options {
+	return minifier.Options{
+		StripComments:      e.flags.StripComments,
+		CollapseBlankLines: e.flags.CollapseBlankLines,
+		KeepDocComments:    e.flags.KeepDocComments,
+	}
+}
+
+// reduce applies the selected content reductions to a file and records the
+// tokens they save in it. Files without a skeletonizer, or that it fails on, are
+// kept in full.
+func (e *Exporter) reduce(file *FileInfo) {
+	content := file.Content
+
+	if skeletonizer, ok := skeletonizers[file.Language]; ok && e.flags.Signatures {
+		skeleton, err := skeletonizer.Skeletonize(content)
+		if err != nil {
+			e.logger.Warn().Err(err).Str("file", file.Path).Msg("Failed to extract signatures, exporting the whole file")
+		} else {
+			content = skeleton
+		}
+	}
+
+	if opts := e.minifyOptions(); opts.Enabled() {
+		content = minifier.Minify(file.Language, content, opts)
+	}
+
+	tokenCount := e.encoding.Count(string(content))
+	file.TokensSaved += file.TokenCount - tokenCount
+	file.Content = content
+	file.TokenCount = tokenCount
+}
+
+// reductionCriteria describes the selected content reductions for the
+// header, ending with the tokens they saved.
+func (e *Exporter) reductionCriteria() []string {
+	if !e.reducing() {
+		return nil
+	}
+
+	var criteria []string
+	if e.flags.Signatures {
+		criteria = append(criteria, fmt.Sprintf("Files in %s are reduced to their declarations and doc comments, with function bodies elided; other files are complete.", strings.Join(skeletonLanguages(), ", ")))
+	}
+
+	var reductions []string
+	switch opts := e.minifyOptions(); {
+	case opts.StripComments && opts.KeepDocComments:
+		reductions = append(reductions, "comments other than doc comments are removed")
+	case opts.StripComments:
+		reductions = append(reductions, "comments are removed")
+	}
+	if e.flags.CollapseBlankLines {
+		reductions = append(reductions, "runs of blank lines are collapsed")
+	}
+	if len(reductions) > 0 {
+		criterion := strings.Join(reductions, " and ")
+		criteria = append(criteria, strings.ToUpper(criterion[:1])+criterion[1:]+".")
+	}
+
+	return append(criteria, fmt.Sprintf("These reductions save %d tokens.", e.tokensSaved))
+}
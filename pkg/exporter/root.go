@@ -0,0 +1,146 @@
+// File: pkg/exporter/root.go
+
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/daemonp/gogpt/pkg/classifier"
+	"github.com/daemonp/gogpt/pkg/git"
+	"github.com/daemonp/gogpt/pkg/gitignore"
+	"github.com/daemonp/gogpt/pkg/languagedetector"
+	"github.com/daemonp/gogpt/pkg/types"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// root is one directory being exported. When an export has more than one
+// root, its label prefixes the paths of its files.
+type root struct {
+	dir   string
+	label string
+	// fsys holds the files of the root: the directory, the tree of a git
+	// revision or a filesystem given instead, for which dir only anchors
+	// the paths.
+	fsys          fs.FS
+	gitIgnore     *gitignore.GitIgnore
+	repo          *git.Repository
+	commit        string
+	fileProcessor *FileProcessor
+}
+
+func openRoot(dir string, flags *types.Flags, useRepo bool) (*root, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	if _, err := os.Stat(absDir); os.IsNotExist(err) {
+		return nil, fmt.Errorf("directory does not exist: %s", absDir)
+	}
+
+	r := &root{dir: absDir, label: filepath.Base(absDir), fsys: os.DirFS(absDir)}
+
+	if useRepo {
+		r.repo, err = git.Open(absDir)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if flags.Rev != "" {
+		r.commit, err = r.repo.ResolveRevision(flags.Rev)
+		if err != nil {
+			return nil, err
+		}
+		r.fsys, err = r.repo.TreeFS(r.commit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list files in %s: %w", flags.Rev, err)
+		}
+	}
+
+	if flags.UseGitIgnore {
+		r.openGitIgnore(log.Logger)
+	}
+	return r, nil
+}
+
+// openFSRoot opens a filesystem as a root labelled name. Its files are
+// anchored under a directory of that name, which is never read.
+func openFSRoot(fsys fs.FS, name string, useGitIgnore bool, logger zerolog.Logger) *root {
+	r := &root{
+		dir:   filepath.Join(string(filepath.Separator), name),
+		label: name,
+		fsys:  fsys,
+	}
+	if useGitIgnore {
+		r.openGitIgnore(logger)
+	}
+	return r
+}
+
+// openGitIgnore reads the .gitignore files of the root's filesystem, so a
+// revision is exported with its own.
+func (r *root) openGitIgnore(logger zerolog.Logger) {
+	var err error
+	r.gitIgnore, err = gitignore.NewGitIgnoreFS(r.fsys, r.dir)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to parse .gitignore files, continuing without gitignore")
+	}
+}
+
+// detectLanguages measures the share of each language among the files of
+// the root the export would not skip as vendored or by the classifier. Files
+// are read with the read timeout, so detection cannot hang on a FIFO, and no
+// more once ctx is cancelled.
+func (r *root) detectLanguages(ctx context.Context, flags *types.Flags) languagedetector.Stats {
+	ignore := func(p string) bool {
+		if !flags.IncludeVendored && classifier.IsVendorDir(path.Base(p)) {
+			return true
+		}
+		return r.gitIgnore != nil && r.gitIgnore.ShouldIgnore(p)
+	}
+	keep := func(p string, content []byte) bool {
+		kind := classifier.Classify(p, content)
+		return kind == "" || kind != classifier.Binary && flags.IncludeGenerated
+	}
+
+	timeout := readTimeout(flags)
+	return languagedetector.DetectShares(r.fsys, languagedetector.Files(r.fsys, ignore), func(p string) ([]byte, error) {
+		return readFSFile(ctx, r.fsys, p, timeout)
+	}, keep)
+}
+
+func (r *root) close() {
+	if r.repo != nil {
+		r.repo.Close()
+	}
+}
+
+// labelRoots makes the labels of roots with the same directory name unique
+// by numbering the repeats, e.g. "lib" and "lib-2".
+func labelRoots(roots []*root) {
+	seen := make(map[string]int)
+	for _, r := range roots {
+		seen[r.label]++
+		if n := seen[r.label]; n > 1 {
+			r.label = fmt.Sprintf("%s-%d", r.label, n)
+		}
+	}
+}
+
+// detectLanguages merges the languages detected in every root.
+func detectLanguages(ctx context.Context, roots []*root, flags *types.Flags) (languagedetector.Stats, error) {
+	sets := make([]languagedetector.Stats, 0, len(roots))
+	for _, r := range roots {
+		sets = append(sets, r.detectLanguages(ctx, flags))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return languagedetector.MergeStats(sets...), nil
+}
@@ -0,0 +1,107 @@
+// File: pkg/exporter/signatures.go
+
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+)
+
+// goSignatures reduces Go source to its API surface: the package clause,
+// imports, type declarations, exported constants, variables and functions,
+// and their doc comments. Function bodies are elided, leaving declarations
+// like `func Open(path string) (*File, error)`.
+func goSignatures(content []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Go source: %w", err)
+	}
+
+	// Comments are kept only within the spans of what is kept, so those of
+	// function bodies and dropped declarations go with them.
+	var spans [][2]token.Pos
+	keep := func(doc *ast.CommentGroup, node ast.Node) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		spans = append(spans, [2]token.Pos{start, node.End()})
+	}
+	keep(file.Doc, file.Name)
+
+	var decls []ast.Decl
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			d.Body = nil
+			keep(d.Doc, d)
+			decls = append(decls, d)
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.IMPORT, token.TYPE:
+				keep(d.Doc, d)
+				decls = append(decls, d)
+			case token.CONST:
+				// Constant groups are kept whole so iota keeps its values.
+				if hasExported(d.Specs) {
+					keep(d.Doc, d)
+					decls = append(decls, d)
+				}
+			case token.VAR:
+				var specs []ast.Spec
+				for _, spec := range d.Specs {
+					if hasExported([]ast.Spec{spec}) {
+						keep(spec.(*ast.ValueSpec).Doc, spec)
+						specs = append(specs, spec)
+					}
+				}
+				if len(specs) > 0 {
+					if d.Doc != nil {
+						spans = append(spans, [2]token.Pos{d.Doc.Pos(), d.Doc.End()})
+					}
+					d.Specs = specs
+					decls = append(decls, d)
+				}
+			}
+		}
+	}
+	file.Decls = decls
+
+	var comments []*ast.CommentGroup
+	for _, group := range file.Comments {
+		for _, span := range spans {
+			if group.Pos() >= span[0] && group.End() <= span[1] {
+				comments = append(comments, group)
+				break
+			}
+		}
+	}
+	file.Comments = comments
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return nil, fmt.Errorf("failed to print Go declarations: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func hasExported(specs []ast.Spec) bool {
+	for _, spec := range specs {
+		if value, ok := spec.(*ast.ValueSpec); ok {
+			for _, name := range value.Names {
+				if name.IsExported() {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+// File: pkg/exporter/signatures_test.go
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGoSignatures(t *testing.T) {
+	source := `// Package store keeps things.
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// Kind is the kind of a thing.
+type Kind int
+
+const (
+	// None is no kind.
+	None Kind = iota
+	Some
+)
+
+var (
+	// ErrMissing is returned for missing things.
+	ErrMissing = errors.New("missing")
+	cache      = map[string]int{}
+)
+
+var mu sync.Mutex
+
+// Store keeps things.
+type Store struct {
+	// Name names the store.
+	Name  string
+	items map[string]int // by key
+}
+
+// Open opens a store.
+func Open(name string) (*Store, error) {
+	// Make the store.
+	return &Store{Name: name}, nil
+}
+
+// Get returns a thing.
+func (s *Store) Get(key string) (int, error) {
+	v, ok := s.items[key]
+	if !ok {
+		return 0, ErrMissing
+	}
+	return v, nil
+}
+
+// helper helps.
+func helper() {}
+`
+
+	expected := `// Package store keeps things.
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// Kind is the kind of a thing.
+type Kind int
+
+const (
+	// None is no kind.
+	None Kind = iota
+	Some
+)
+
+var (
+	// ErrMissing is returned for missing things.
+	ErrMissing = errors.New("missing")
+)
+
+// Store keeps things.
+type Store struct {
+	// Name names the store.
+	Name  string
+	items map[string]int // by key
+}
+
+// Open opens a store.
+func Open(name string) (*Store, error)
+
+// Get returns a thing.
+func (s *Store) Get(key string) (int, error)
+`
+
+	signatures, err := goSignatures([]byte(source))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(signatures))
+
+	_, err = goSignatures([]byte("package main\nfunc {"))
+	assert.Error(t, err)
+}
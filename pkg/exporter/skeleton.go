@@ -0,0 +1,220 @@
+// File: pkg/exporter/skeleton.go
+
+package exporter
+
+import (
+	"bytes"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/daemonp/gogpt/pkg/minifier"
+)
+
+// Skeletonizer reduces the source of one language to its skeleton: its
+// declarations and their doc comments, without implementation bodies.
+type Skeletonizer interface {
+	Skeletonize(content []byte) ([]byte, error)
+}
+
+// SkeletonizerFunc adapts a function to a Skeletonizer.
+type SkeletonizerFunc func(content []byte) ([]byte, error)
+
+func (f SkeletonizerFunc) Skeletonize(content []byte) ([]byte, error) {
+	return f(content)
+}
+
+// skeletonizers maps languages, as named by the languagedetector package,
+// to their skeletonizers. Files of other languages are exported in full.
+var skeletonizers = map[string]Skeletonizer{
+	"go":     SkeletonizerFunc(goSignatures),
+	"python": SkeletonizerFunc(pythonSkeleton),
+	"js":     SkeletonizerFunc(jsSkeleton),
+	"ts":     SkeletonizerFunc(jsSkeleton),
+	"java":   SkeletonizerFunc(javaSkeleton),
+}
+
+// RegisterSkeletonizer sets the skeletonizer of a language, replacing any
+// existing one. It is not safe to call during an export.
+func RegisterSkeletonizer(language string, skeletonizer Skeletonizer) {
+	skeletonizers[language] = skeletonizer
+}
+
+// skeletonLanguages returns the languages that have a skeletonizer.
+func skeletonLanguages() []string {
+	languages := make([]string, 0, len(skeletonizers))
+	for language := range skeletonizers {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// elidedBody replaces the bodies of functions in brace languages.
+const elidedBody = "{ ... }"
+
+var (
+	javaTypeDeclaration = regexp.MustCompile(`\b(class|interface|enum|record)\b`)
+	jsTypeDeclaration   = regexp.MustCompile(`(^|[\s=(,])(class|interface|enum|namespace|module\s)`)
+)
+
+// javaSkeleton keeps package, import, type and field declarations and
+// elides the bodies of methods, constructors, lambdas and initializers.
+func javaSkeleton(content []byte) ([]byte, error) {
+	return braceSkeleton("java", content, nil, func(header string) bool {
+		if javaTypeDeclaration.MatchString(header) {
+			return false
+		}
+		return header == "" || header == "static" || strings.HasSuffix(header, "->") || isSignature(header, "throws")
+	}), nil
+}
+
+// jsSkeleton keeps imports, exports, classes, interfaces, types and object
+// literals and elides the bodies of functions, methods and arrow functions.
+func jsSkeleton(content []byte) ([]byte, error) {
+	return braceSkeleton("js", content, jsTypeAnnotation, func(header string) bool {
+		header = emptyBraces(header)
+		if jsTypeDeclaration.MatchString(header) {
+			return false
+		}
+		return strings.HasSuffix(header, "=>") || isSignature(header, ":")
+	}), nil
+}
+
+// jsTypeAnnotation reports whether a brace opens an object type in the
+// return type annotation of a signature, as in "f(): Promise<{ a: string }>",
+// rather than the body: the annotation still expects a type.
+func jsTypeAnnotation(header string) bool {
+	header = emptyBraces(header)
+	paren := strings.LastIndexByte(header, ')')
+	if paren < 0 {
+		return false
+	}
+	after := strings.TrimSpace(header[paren+1:])
+	if !strings.HasPrefix(after, ":") {
+		return false
+	}
+	annotation := strings.TrimSpace(after[1:])
+	if annotation == "" {
+		return true
+	}
+	for _, operator := range []string{"<", "|", "&", ",", "(", "["} {
+		if strings.HasSuffix(annotation, operator) {
+			return true
+		}
+	}
+	return false
+}
+
+// emptyBraces replaces the content of the outermost braces of a header with
+// nothing, so object types do not look like parameter lists.
+func emptyBraces(header string) string {
+	if !strings.Contains(header, "{") {
+		return header
+	}
+
+	var b strings.Builder
+	depth := 0
+	for i := 0; i < len(header); i++ {
+		switch c := header[i]; {
+		case c == '{':
+			if depth == 0 {
+				b.WriteByte(c)
+			}
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+			if depth == 0 {
+				b.WriteByte(c)
+			}
+		case depth == 0:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isSignature reports whether a block header ends with a parameter list,
+// optionally followed by a clause starting with suffix, like a return type.
+func isSignature(header, suffix string) bool {
+	paren := strings.LastIndexByte(header, ')')
+	if paren < 0 {
+		return false
+	}
+	after := strings.TrimSpace(header[paren+1:])
+	return after == "" || strings.HasPrefix(after, suffix)
+}
+
+// braceSkeleton copies source in a brace language, replacing every block
+// for which elide returns true with elidedBody. elide is given the code
+// between the end of the previous statement or block and the opening brace,
+// with comments and strings blanked out and spaces trimmed. Braces for which
+// inHeader, if not nil, returns true are kept as part of the header.
+func braceSkeleton(language string, content []byte, inHeader, elide func(header string) bool) []byte {
+	code := minifier.Code(language, content)
+
+	var out bytes.Buffer
+	last, headerStart := 0, 0
+	for i := 0; i < len(content); i++ {
+		if !code[i] {
+			continue
+		}
+
+		switch content[i] {
+		case ';', '}':
+			headerStart = i + 1
+		case '{':
+			header := codeText(content[headerStart:i], code[headerStart:i])
+			if inHeader != nil && inHeader(header) {
+				i = matchingBrace(content, code, i)
+				continue
+			}
+			if !elide(header) {
+				headerStart = i + 1
+				continue
+			}
+
+			end := matchingBrace(content, code, i)
+			out.Write(content[last:i])
+			out.WriteString(elidedBody)
+			last, i, headerStart = end+1, end, end+1
+		}
+	}
+	out.Write(content[last:])
+	return out.Bytes()
+}
+
+// codeText returns the code in source with everything else replaced by
+// spaces, trimmed and with runs of spaces collapsed.
+func codeText(source []byte, code []bool) string {
+	text := make([]byte, len(source))
+	for i, b := range source {
+		if code[i] {
+			text[i] = b
+		} else {
+			text[i] = ' '
+		}
+	}
+	return strings.Join(strings.Fields(string(text)), " ")
+}
+
+// matchingBrace returns the position of the brace closing the one at open,
+// or the end of the content if it is never closed.
+func matchingBrace(content []byte, code []bool, open int) int {
+	depth := 0
+	for i := open; i < len(content); i++ {
+		if !code[i] {
+			continue
+		}
+		switch content[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(content) - 1
+}
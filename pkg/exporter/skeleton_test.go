@@ -0,0 +1,261 @@
+// File: pkg/exporter/skeleton_test.go
+
+package exporter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkeletonize(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Python",
+			language: "python",
+			input: `"""Module docs."""
+import os
+from typing import List
+
+LIMIT = 10
+
+if os.name == "nt":
+    LIMIT = 5
+
+
+@dataclass
+class Item:
+    """An item."""
+
+    name: str
+    count: int = 0
+
+    def total(self, price: float) -> float:
+        """Returns the total."""
+        s = "def nested(): pass"
+        return self.count * price
+
+    async def fetch(
+        self,
+        url: str,
+    ):
+        data = await get(url)
+        return data
+
+
+def main():
+    print("hello")
+
+
+class Empty:
+    pass
+
+
+class Error(Exception): pass
+
+
+class Client:
+    async def q(self) -> dict[str, int]: return await z()
+    def r(self, f=lambda: 1): return f()  # inline
+    def s(self):  # not inline
+        return 1
+`,
+			expected: `"""Module docs."""
+import os
+from typing import List
+LIMIT = 10
+
+@dataclass
+class Item:
+    """An item."""
+    name: str
+    count: int = 0
+
+    def total(self, price: float) -> float:
+        """Returns the total."""
+        ...
+
+    async def fetch(
+        self,
+        url: str,
+    ):
+        ...
+
+def main():
+    ...
+
+class Empty:
+    ...
+
+class Error(Exception): ...
+
+class Client:
+
+    async def q(self) -> dict[str, int]: ...
+
+    def r(self, f=lambda: 1): ...
+
+    def s(self):  # not inline
+        ...
+`,
+		},
+		{
+			name:     "TypeScript",
+			language: "ts",
+			input: `import { get } from "./http";
+
+/** A user. */
+export interface User {
+  name: string;
+}
+
+export class Service {
+  private cache = new Map<string, User>();
+
+  constructor(private readonly base: string) {
+    this.base = base.replace(/\/$/, "");
+  }
+
+  /** Loads a user. */
+  async load(id: string): Promise<User> {
+    const url = ` + "`${this.base}/users/${id}`" + `;
+    return get(url);
+  }
+}
+
+export const handler = async (event: Event) => {
+  if (event) { return "}"; }
+};
+
+export default { name: "service" };
+`,
+			expected: `import { get } from "./http";
+
+/** A user. */
+export interface User {
+  name: string;
+}
+
+export class Service {
+  private cache = new Map<string, User>();
+
+  constructor(private readonly base: string) { ... }
+
+  /** Loads a user. */
+  async load(id: string): Promise<User> { ... }
+}
+
+export const handler = async (event: Event) => { ... };
+
+export default { name: "service" };
+`,
+		},
+		{
+			name:     "TypeScript object return types",
+			language: "ts",
+			input: `export async function load(id: string): Promise<{ name: string }> {
+  return { name: id };
+}
+
+export function pair(): { a: number; b: number } {
+  return { a: 1, b: 2 };
+}
+
+export const make = (): { ok: boolean } => {
+  return { ok: true };
+};
+
+export function map(f: (x: number) => number): Array<{ f: (x: number) => number }> {
+  return [{ f }];
+}
+`,
+			expected: `export async function load(id: string): Promise<{ name: string }> { ... }
+
+export function pair(): { a: number; b: number } { ... }
+
+export const make = (): { ok: boolean } => { ... };
+
+export function map(f: (x: number) => number): Array<{ f: (x: number) => number }> { ... }
+`,
+		},
+		{
+			name:     "Java",
+			language: "java",
+			input: `package com.example;
+
+import java.util.List;
+
+/** Greets people. */
+public class Greeter implements Runnable {
+    private static final String PREFIX = "Hello, {";
+
+    static {
+        System.loadLibrary("greet");
+    }
+
+    public Greeter(String name) {
+        this.name = name;
+    }
+
+    @Override
+    public void run() {
+        list.forEach(n -> { System.out.println(n); });
+    }
+
+    public List<String> names() throws IOException {
+        return List.of();
+    }
+
+    enum Mode { LOUD, QUIET }
+}
+`,
+			expected: `package com.example;
+
+import java.util.List;
+
+/** Greets people. */
+public class Greeter implements Runnable {
+    private static final String PREFIX = "Hello, {";
+
+    static { ... }
+
+    public Greeter(String name) { ... }
+
+    @Override
+    public void run() { ... }
+
+    public List<String> names() throws IOException { ... }
+
+    enum Mode { LOUD, QUIET }
+}
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			skeleton, err := skeletonizers[tt.language].Skeletonize([]byte(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(skeleton))
+		})
+	}
+}
+
+func TestRegisterSkeletonizer(t *testing.T) {
+	defer delete(skeletonizers, "ruby")
+
+	RegisterSkeletonizer("ruby", SkeletonizerFunc(func(content []byte) ([]byte, error) {
+		return []byte("# skeleton\n"), nil
+	}))
+	assert.Contains(t, skeletonLanguages(), "ruby")
+
+	skeleton, err := skeletonizers["ruby"].Skeletonize([]byte("puts 1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "# skeleton\n", string(skeleton))
+}
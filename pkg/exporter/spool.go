@@ -0,0 +1,123 @@
+// File: pkg/exporter/spool.go
+
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/rs/zerolog"
+)
+
+// spoolMemory is how much content the spool holds in memory before it moves
+// to a temporary file.
+const spoolMemory = 32 << 20
+
+// spool holds the contents of the scanned files from when they are processed
+// until they are written, so an export keeps only the metadata of its files
+// in memory however large the tree. Small exports stay in memory; larger ones
+// are moved to a temporary file. The short notices of excluded files are not
+// spooled.
+type spool struct {
+	mu sync.Mutex
+	// dir is where the temporary file is created, os.TempDir() when empty.
+	dir string
+	// limit is the size past which the content moves to a temporary file.
+	limit  int64
+	mem    []byte
+	file   *os.File
+	size   int64
+	logger zerolog.Logger
+}
+
+// spoolSpan locates a file's content in the spool.
+type spoolSpan struct {
+	offset int64
+	length int
+}
+
+func newSpool(dir string, logger zerolog.Logger) *spool {
+	return &spool{dir: dir, limit: spoolMemory, logger: logger}
+}
+
+// store moves a file's content to the spool, releasing it from memory.
+func (s *spool) store(file *FileInfo) error {
+	if file.Excluded || file.spooled != nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil && s.size+int64(len(file.Content)) > s.limit {
+		if err := s.spill(); err != nil {
+			return err
+		}
+	}
+
+	if s.file == nil {
+		s.mem = append(s.mem, file.Content...)
+	} else if _, err := s.file.WriteAt(file.Content, s.size); err != nil {
+		return fmt.Errorf("failed to spool %s: %w", file.Path, err)
+	}
+	file.spooled = &spoolSpan{offset: s.size, length: len(file.Content)}
+	file.Content = nil
+	s.size += int64(file.spooled.length)
+	return nil
+}
+
+// spill moves the content held in memory to a temporary file.
+func (s *spool) spill() error {
+	file, err := os.CreateTemp(s.dir, "gogpt-spool-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file: %w", err)
+	}
+	if _, err := file.Write(s.mem); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	s.file, s.mem = file, nil
+	return nil
+}
+
+// load returns a copy of a file with its content read back from the spool.
+// Files that are not spooled are returned as they are.
+func (s *spool) load(file FileInfo) (FileInfo, error) {
+	if file.spooled == nil {
+		return file, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	content := make([]byte, file.spooled.length)
+	if s.file == nil {
+		copy(content, s.mem[file.spooled.offset:])
+	} else if _, err := s.file.ReadAt(content, file.spooled.offset); err != nil {
+		return file, fmt.Errorf("failed to read %s back from the spool: %w", file.Path, err)
+	}
+	file.Content = content
+	file.spooled = nil
+	return file, nil
+}
+
+// content returns a file's content, reading it back from the spool if
+// needed, or nil if it cannot be read.
+func (s *spool) content(file FileInfo) []byte {
+	loaded, err := s.load(file)
+	if err != nil {
+		s.logger.Warn().Err(err).Str("file", file.Path).Msg("Failed to read spooled file")
+		return nil
+	}
+	return loaded.Content
+}
+
+func (s *spool) close() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+	s.mem = nil
+}
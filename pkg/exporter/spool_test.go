@@ -0,0 +1,66 @@
+// File: pkg/exporter/spool_test.go
+
+package exporter
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpool(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int64
+		// onDisk is whether the content ends up in a temporary file.
+		onDisk bool
+	}{
+		{name: "In memory", limit: spoolMemory},
+		{name: "Spilled to disk", limit: 16, onDisk: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s := newSpool(dir, zerolog.Nop())
+			s.limit = tt.limit
+
+			files := []FileInfo{
+				{Path: "a.go", Content: []byte("package a\n")},
+				{Path: "empty.go", Content: []byte{}},
+				{Path: "b.go", Content: []byte("package b\n\nfunc B() {}\n")},
+				{Path: "big.go", Content: []byte("// File excluded due to size: 5000 tokens"), Excluded: true},
+			}
+			for i := range files {
+				require.NoError(t, s.store(&files[i]))
+			}
+
+			assert.Nil(t, files[0].Content)
+			assert.Equal(t, 10, files[0].contentSize())
+			assert.Equal(t, 0, files[1].contentSize())
+			assert.Equal(t, "// File excluded due to size: 5000 tokens", string(files[3].Content))
+
+			// Files are read back in any order.
+			for _, i := range []int{2, 0, 1, 3} {
+				loaded, err := s.load(files[i])
+				require.NoError(t, err)
+				assert.Nil(t, loaded.spooled)
+				assert.Equal(t, files[i].contentSize(), len(loaded.Content))
+			}
+			assert.Equal(t, "package a\n", string(s.content(files[0])))
+			assert.Equal(t, "package b\n\nfunc B() {}\n", string(s.content(files[2])))
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Equal(t, tt.onDisk, len(entries) == 1)
+
+			s.close()
+			entries, err = os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
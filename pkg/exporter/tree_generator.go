@@ -5,52 +5,188 @@ package exporter
 import (
 	"bytes"
 	"fmt"
-	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
+	"github.com/daemonp/gogpt/pkg/git"
 	"github.com/ddddddO/gtree"
 )
 
-type TreeGenerator struct{}
+// Markers before the names of files left out of the export, explained by a
+// legend under the tree.
+const (
+	markerSize      = "⊗"
+	markerGitIgnore = "⊘"
+	markerFiltered  = "⊖"
+)
+
+var markerLegend = []struct{ marker, meaning string }{
+	{markerSize, "excluded by size"},
+	{markerGitIgnore, "ignored by .gitignore"},
+	{markerFiltered, "filtered out or skipped"},
+}
 
-func NewTreeGenerator() *TreeGenerator {
-	return &TreeGenerator{}
+// TreeGenerator renders the directory structure of the exported files.
+type TreeGenerator struct {
+	// annotate adds each file's size and token count to the tree, and each
+	// directory's total of exported tokens.
+	annotate bool
 }
 
-func (tg *TreeGenerator) Generate(files []FileInfo) (string, error) {
-	if len(files) == 0 {
-		return "## Repository Structure\n\n(empty)", nil
+func NewTreeGenerator(annotate bool) *TreeGenerator {
+	return &TreeGenerator{annotate: annotate}
+}
+
+// treeNode is a directory or file in the trie of exported paths. Children
+// are kept in the order they were first seen, which is the export order.
+type treeNode struct {
+	name     string
+	file     *FileInfo
+	children []*treeNode
+	byName   map[string]*treeNode
+	// hidden is the reason a file or directory is not exported, when it is
+	// shown in the tree anyway.
+	hidden string
+	// tokens is the total of exported tokens in a directory.
+	tokens int
+}
+
+func (n *treeNode) child(name string) *treeNode {
+	if child, ok := n.byName[name]; ok {
+		return child
 	}
+	child := &treeNode{name: name, byName: make(map[string]*treeNode)}
+	n.byName[name] = child
+	n.children = append(n.children, child)
+	return child
+}
 
-	var (
-		root *gtree.Node
-		node *gtree.Node
-	)
+// total sets the token totals of a directory and the directories under it.
+func (n *treeNode) total() int {
+	if n.file != nil {
+		if n.file.Excluded {
+			return 0
+		}
+		return n.file.TokenCount
+	}
+	n.tokens = 0
+	for _, child := range n.children {
+		n.tokens += child.total()
+	}
+	return n.tokens
+}
 
-	for _, file := range files {
-		splited := strings.Split(file.Path, string(os.PathSeparator))
+// Generate renders the files as a tree under rootName, usually the name of
+// the exported directory. Hidden files and directories, left out of the
+// export, are shown after the exported files of their directory with a
+// marker for the reason.
+func (tg *TreeGenerator) Generate(rootName string, files []FileInfo, hidden []SkippedFile) (string, error) {
+	if len(files) == 0 && len(hidden) == 0 {
+		return "(empty)\n", nil
+	}
 
-		for i, s := range splited {
-			if root == nil {
-				root = gtree.NewRoot(s)
-				node = root
-				continue
-			}
-			if i == 0 {
-				continue
-			}
+	trie := &treeNode{name: rootName, byName: make(map[string]*treeNode)}
+	for i := range files {
+		node := trie
+		for _, segment := range strings.Split(filepath.ToSlash(files[i].Path), "/") {
+			node = node.child(segment)
+		}
+		node.file = &files[i]
+	}
 
-			tmp := node.Add(s)
-			node = tmp
+	// Sorted by path, hidden directories come before anything hidden within
+	// them, which is then left out.
+	hidden = append([]SkippedFile(nil), hidden...)
+	sort.Slice(hidden, func(i, j int) bool {
+		return filepath.ToSlash(hidden[i].Path) < filepath.ToSlash(hidden[j].Path)
+	})
+	for _, entry := range hidden {
+		node := trie
+		for _, segment := range strings.Split(filepath.ToSlash(entry.Path), "/") {
+			if node = node.child(segment); node.hidden != "" {
+				break
+			}
+		}
+		if node.file == nil && node.hidden == "" && len(node.children) == 0 {
+			node.hidden = entry.Reason
 		}
-		node = root
 	}
+	trie.total()
+
+	used := make(map[string]bool)
+	root := gtree.NewRoot(tg.label(trie, used))
+	tg.add(root, trie, used)
 
 	var buffer bytes.Buffer
-	buffer.WriteString("## Repository Structure\n\n")
 	if err := gtree.OutputProgrammably(&buffer, root); err != nil {
 		return "", fmt.Errorf("failed to generate tree structure: %w", err)
 	}
 
+	var legend []string
+	for _, entry := range markerLegend {
+		if used[entry.marker] {
+			legend = append(legend, entry.marker+" "+entry.meaning)
+		}
+	}
+	if len(legend) > 0 {
+		fmt.Fprintf(&buffer, "\n%s\n", strings.Join(legend, ", "))
+	}
+
 	return buffer.String(), nil
 }
+
+func (tg *TreeGenerator) add(parent *gtree.Node, node *treeNode, used map[string]bool) {
+	for _, child := range node.children {
+		tg.add(parent.Add(tg.label(child, used)), child, used)
+	}
+}
+
+// label names a node in the tree, after the marker of a file left out of
+// the export and followed by its notes: the git status and, when
+// annotating, the size and token count of a file or the token total of a
+// directory. The markers used are recorded in used.
+func (tg *TreeGenerator) label(node *treeNode, used map[string]bool) string {
+	name := node.name
+	var notes []string
+
+	switch {
+	case node.hidden != "":
+		marker := markerFiltered
+		if node.hidden == FilteredGitIgnore {
+			marker = markerGitIgnore
+		}
+		used[marker] = true
+		name = marker + " " + name
+		if node.hidden != FilteredGitIgnore && node.hidden != FilteredOut {
+			notes = append(notes, node.hidden)
+		}
+	case node.file != nil:
+		if node.file.Status != "" {
+			notes = append(notes, node.file.Status)
+		}
+		if node.file.Excluded && node.file.Status != git.StatusDeleted {
+			used[markerSize] = true
+			name = markerSize + " " + name
+			if tg.annotate {
+				notes = append(notes, fmt.Sprintf("%d tokens", node.file.TokenCount))
+			}
+		} else if tg.annotate && !node.file.Excluded {
+			notes = append(notes, formatSize(node.file.contentSize()), fmt.Sprintf("%d tokens", node.file.TokenCount))
+		}
+	case tg.annotate:
+		notes = append(notes, fmt.Sprintf("%d tokens", node.tokens))
+	}
+
+	if len(notes) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(notes, ", "))
+}
+
+func formatSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024.0)
+}
@@ -0,0 +1,109 @@
+// File: pkg/exporter/tree_generator_test.go
+
+package exporter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/daemonp/gogpt/pkg/classifier"
+	"github.com/daemonp/gogpt/pkg/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTreeGenerator(t *testing.T) {
+	files := []FileInfo{
+		{Path: "README.md", Content: []byte("# Repo\n"), TokenCount: 3},
+		{Path: "cmd/app/main.go", Content: []byte("package main\n"), TokenCount: 3, Status: git.StatusModified},
+		{Path: "pkg/a/a.go", Content: []byte(strings.Repeat("x", 2048)), TokenCount: 256},
+		{Path: "pkg/a/big.go", Content: []byte("// File excluded due to size: 5000 tokens"), TokenCount: 5000, Excluded: true},
+		{Path: "pkg/b.go", Content: []byte("package pkg\n"), TokenCount: 3},
+		{Path: "old.go", Content: []byte("// File deleted"), TokenCount: 4, Excluded: true, Status: git.StatusDeleted},
+	}
+	hidden := []SkippedFile{
+		{Path: "web/node_modules/x.txt", Reason: FilteredOut},
+		{Path: "pkg/a/notes.txt", Reason: FilteredOut},
+		{Path: "web/node_modules", Reason: classifier.Vendored},
+		{Path: "dist", Reason: FilteredGitIgnore},
+		{Path: "pkg/logo.png", Reason: classifier.Binary},
+	}
+
+	tests := []struct {
+		name     string
+		annotate bool
+		hidden   []SkippedFile
+		expected string
+	}{
+		{
+			name: "Directories are shared under the root",
+			expected: `repo
+├── README.md
+├── cmd
+│   └── app
+│       └── main.go (modified)
+├── pkg
+│   ├── a
+│   │   ├── a.go
+│   │   └── ⊗ big.go
+│   └── b.go
+└── old.go (deleted)
+
+⊗ excluded by size
+`,
+		},
+		{
+			name:     "Annotations show sizes and token totals",
+			annotate: true,
+			expected: `repo (265 tokens)
+├── README.md (7 B, 3 tokens)
+├── cmd (3 tokens)
+│   └── app (3 tokens)
+│       └── main.go (modified, 13 B, 3 tokens)
+├── pkg (259 tokens)
+│   ├── a (256 tokens)
+│   │   ├── a.go (2.0 KB, 256 tokens)
+│   │   └── ⊗ big.go (5000 tokens)
+│   └── b.go (12 B, 3 tokens)
+└── old.go (deleted)
+
+⊗ excluded by size
+`,
+		},
+		{
+			name:   "Hidden files are marked after the exported ones",
+			hidden: hidden,
+			expected: `repo
+├── README.md
+├── cmd
+│   └── app
+│       └── main.go (modified)
+├── pkg
+│   ├── a
+│   │   ├── a.go
+│   │   ├── ⊗ big.go
+│   │   └── ⊖ notes.txt
+│   ├── b.go
+│   └── ⊖ logo.png (binary)
+├── old.go (deleted)
+├── ⊘ dist
+└── web
+    └── ⊖ node_modules (vendored)
+
+⊗ excluded by size, ⊘ ignored by .gitignore, ⊖ filtered out or skipped
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			structure, err := NewTreeGenerator(tt.annotate).Generate("repo", files, tt.hidden)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, structure)
+		})
+	}
+
+	structure, err := NewTreeGenerator(false).Generate("repo", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "(empty)\n", structure)
+}
@@ -1,38 +1,38 @@
 package exporter
 
 import (
-	"fmt"
 	"io"
-
-	"github.com/daemonp/gogpt/pkg/fileutils"
 )
 
+// Writer writes an export to an output in the configured format.
 type Writer struct {
 	output io.Writer
+	format Format
+	files  int
 }
 
-func NewWriter(output io.Writer) *Writer {
-	return &Writer{output: output}
+func NewWriter(output io.Writer, format Format) *Writer {
+	return &Writer{output: output, format: format}
 }
 
-func (w *Writer) Write(content string) {
-	fmt.Fprint(w.output, content)
+func (w *Writer) WriteHeader(header Header) error {
+	return w.format.WriteHeader(w.output, header)
 }
 
 func (w *Writer) WriteFileContents(files []FileInfo) error {
 	for _, file := range files {
-		w.writeFileContent(file)
+		if err := w.WriteFile(file); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (w *Writer) writeFileContent(file FileInfo) {
-	fmt.Fprintf(w.output, "// File: %s\n", file.Path)
-	if file.Excluded {
-		fmt.Fprintf(w.output, "%s\n\n", file.Content)
-		return
-	}
-	fmt.Fprintf(w.output, "```%s\n", fileutils.GetFileExtension(file.Path))
-	fmt.Fprintf(w.output, "%s\n", file.Content)
-	fmt.Fprintf(w.output, "```\n\n")
+func (w *Writer) WriteFile(file FileInfo) error {
+	w.files++
+	return w.format.WriteFile(w.output, file, w.files)
+}
+
+func (w *Writer) WriteFooter(summary Summary) error {
+	return w.format.WriteFooter(w.output, summary)
 }
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// XMLFormat wraps each section and file in XML tags, the layout Claude-style
+// prompts expect. Text is escaped, but file contents and diffs are written in
+// CDATA sections, as models read raw code more reliably than entity-escaped
+// code; a "]]>" in them is split across two sections.
+type XMLFormat struct{}
+
+func (f *XMLFormat) Extension() string {
+	return ".xml"
+}
+
+func (f *XMLFormat) WriteHeader(w io.Writer, header Header) error {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<repository_export title=%s>\n", xmlAttr(header.Title)))
+	sb.WriteString("<description>\n")
+	sb.WriteString(exportDescription + "\n")
+	for _, criterion := range header.Criteria {
+		sb.WriteString(fmt.Sprintf("* %s\n", xmlText(criterion)))
+	}
+	sb.WriteString("</description>\n")
+
+	if len(header.Dropped) > 0 {
+		sb.WriteString("<dropped_files>\n")
+		for _, d := range header.Dropped {
+			sb.WriteString(fmt.Sprintf("<file path=%s tokens=\"%d\" reason=%s/>\n", xmlAttr(d.Path), d.TokenCount, xmlAttr(d.Reason)))
+		}
+		sb.WriteString("</dropped_files>\n")
+	}
+
+	writeXMLTrees(&sb, header.Trees)
+	sb.WriteString("<files>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteTrees writes the structures in a repository_export element of their
+// own.
+func (f *XMLFormat) WriteTrees(w io.Writer, trees []Tree) error {
+	var sb strings.Builder
+	sb.WriteString("<repository_export>\n")
+	writeXMLTrees(&sb, trees)
+	sb.WriteString("</repository_export>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeXMLTrees(sb *strings.Builder, trees []Tree) {
+	for _, tree := range trees {
+		if tree.Label != "" {
+			sb.WriteString(fmt.Sprintf("<repository_structure root=%s>\n", xmlAttr(tree.Label)))
+		} else {
+			sb.WriteString("<repository_structure>\n")
+		}
+		sb.WriteString(xmlText(tree.Structure))
+		if !strings.HasSuffix(tree.Structure, "\n") {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("</repository_structure>\n")
+	}
+}
+
+func (f *XMLFormat) WriteFile(w io.Writer, file FileInfo, index int) error {
+	attrs := fmt.Sprintf("index=\"%d\" path=%s tokens=\"%d\"", index, xmlAttr(file.Path), file.TokenCount)
+	if file.Segment != "" {
+		attrs += fmt.Sprintf(" segment=%s", xmlAttr(file.Segment))
+	}
+	if file.Status != "" {
+		attrs += fmt.Sprintf(" status=%s", xmlAttr(file.Status))
+	}
+	if file.Excluded {
+		attrs += " excluded=\"true\""
+	}
+
+	if _, err := fmt.Fprintf(w, "<file %s>\n%s\n</file>\n", attrs, xmlCDATA(file.Content)); err != nil {
+		return err
+	}
+
+	if len(file.Diff) == 0 {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "<diff path=%s>\n%s\n</diff>\n", xmlAttr(file.Path), xmlCDATA(file.Diff))
+	return err
+}
+
+func (f *XMLFormat) WriteFooter(w io.Writer, summary Summary) error {
+	var sb strings.Builder
+
+	sb.WriteString("</files>\n")
+	if summary.Chunks != nil {
+		sb.WriteString(fmt.Sprintf("<manifest parts=\"%d\">\n", len(summary.Chunks)))
+		for i, chunk := range summary.Chunks {
+			current := ""
+			if i == summary.Part {
+				current = " current=\"true\""
+			}
+			sb.WriteString(fmt.Sprintf("<part number=\"%d\"%s>%s</part>\n", i+1, current, xmlText(strings.Join(chunkNames(chunk), ", "))))
+		}
+		sb.WriteString("</manifest>\n")
+	}
+	sb.WriteString("</repository_export>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func xmlAttr(s string) string {
+	return `"` + xmlText(s) + `"`
+}
+
+// xmlCDATA wraps content in a CDATA section, ending the section inside any
+// "]]>" so that it is kept as text.
+func xmlCDATA(content []byte) string {
+	return "<![CDATA[" + strings.ReplaceAll(string(content), "]]>", "]]]]><![CDATA[>") + "]]>"
+}
+
+func xmlText(s string) string {
+	var sb strings.Builder
+	_ = xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
@@ -0,0 +1,48 @@
+// File: pkg/exporter/xml_format_test.go
+
+package exporter
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestXMLFormatWriteFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"Plain file", "package main\n\nfunc main() {}"},
+		{"Markup", "const tmpl = `<file path=\"x\">&amp;</file>`"},
+		{"CDATA end", "x := a[b[c]]>0 // ]]>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format := &XMLFormat{}
+			var buf bytes.Buffer
+			require.NoError(t, format.WriteHeader(&buf, Header{Title: "Export", Criteria: []string{"Files <a & b> are skipped."}, Trees: []Tree{{Structure: "repo\n└── a&b.go\n"}}}))
+			require.NoError(t, format.WriteFile(&buf, FileInfo{Path: "main.go", Content: []byte(tt.content), Diff: []byte("+" + tt.content)}, 1))
+			require.NoError(t, format.WriteFooter(&buf, Summary{Files: 1}))
+
+			var doc struct {
+				Structure string `xml:"repository_structure"`
+				Files     []struct {
+					Path    string `xml:"path,attr"`
+					Content string `xml:",chardata"`
+				} `xml:"files>file"`
+				Diff string `xml:"files>diff"`
+			}
+			require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc), buf.String())
+			require.Len(t, doc.Files, 1)
+			assert.Equal(t, "main.go", doc.Files[0].Path)
+			assert.Equal(t, "\n"+tt.content+"\n", doc.Files[0].Content)
+			assert.Equal(t, "\n+"+tt.content+"\n", doc.Diff)
+			assert.Equal(t, "\nrepo\n└── a&b.go\n", doc.Structure)
+		})
+	}
+}
@@ -32,6 +32,97 @@ var LanguageExtensions = map[string][]string{
 	"docker":     {"Dockerfile"},
 	"make":       {"Makefile"},
 	"config":     {".cfg", ".conf", ".ini"},
+	"groovy":     {".groovy", ".gradle"},
+	"starlark":   {".bzl", ".bazel", ".star"},
+	"perl":       {".pl", ".pm"},
+}
+
+// languageFences maps languages to Markdown code fence language identifiers,
+// for files whose language does not follow from their extension.
+var languageFences = map[string]string{
+	"js":     "javascript",
+	"ts":     "typescript",
+	"shell":  "bash",
+	"docker": "dockerfile",
+	"make":   "makefile",
+	"config": "ini",
+}
+
+// fenceLanguages maps extensions, or base names for files without one, to
+// Markdown code fence language identifiers.
+var fenceLanguages = map[string]string{
+	"go":         "go",
+	"js":         "javascript",
+	"jsx":        "jsx",
+	"mjs":        "javascript",
+	"cjs":        "javascript",
+	"ts":         "typescript",
+	"tsx":        "tsx",
+	"rb":         "ruby",
+	"erb":        "erb",
+	"py":         "python",
+	"java":       "java",
+	"c":          "c",
+	"h":          "c",
+	"cpp":        "cpp",
+	"hpp":        "cpp",
+	"cc":         "cpp",
+	"hh":         "cpp",
+	"cs":         "csharp",
+	"php":        "php",
+	"swift":      "swift",
+	"rs":         "rust",
+	"kt":         "kotlin",
+	"kts":        "kotlin",
+	"scala":      "scala",
+	"html":       "html",
+	"htm":        "html",
+	"css":        "css",
+	"md":         "markdown",
+	"markdown":   "markdown",
+	"yaml":       "yaml",
+	"yml":        "yaml",
+	"json":       "json",
+	"xml":        "xml",
+	"sql":        "sql",
+	"sh":         "bash",
+	"bash":       "bash",
+	"ps1":        "powershell",
+	"toml":       "toml",
+	"cfg":        "ini",
+	"conf":       "ini",
+	"ini":        "ini",
+	"mod":        "go",
+	"dockerfile": "dockerfile",
+	"makefile":   "makefile",
+	"groovy":     "groovy",
+	"gradle":     "groovy",
+	"bzl":        "starlark",
+	"bazel":      "starlark",
+	"star":       "starlark",
+	"pl":         "perl",
+	"pm":         "perl",
+}
+
+// FenceLanguage returns the Markdown code fence language for a file, falling
+// back to its lowercased extension, or "" for unknown files without one.
+func FenceLanguage(path string) string {
+	if lang, ok := fenceLanguages[strings.ToLower(GetFileExtension(path))]; ok {
+		return lang
+	}
+	if ext := filepath.Ext(path); ext != "" {
+		return strings.ToLower(strings.TrimPrefix(ext, "."))
+	}
+	return ""
+}
+
+// LanguageFence returns the Markdown code fence language for a language
+// name, e.g. "bash" for "shell".
+func LanguageFence(lang string) string {
+	if fence, ok := languageFences[lang]; ok {
+		return fence
+	}
+	return lang
 }
 
 func GetFileExtension(path string) string {
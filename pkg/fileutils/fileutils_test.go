@@ -50,3 +50,43 @@ func TestIsLanguageFile(t *testing.T) {
 		})
 	}
 }
+
+func TestFenceLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		expected string
+	}{
+		{"Go file", "main.go", "go"},
+		{"JavaScript file", "src/app.js", "javascript"},
+		{"Dockerfile", "build/Dockerfile", "dockerfile"},
+		{"Makefile", "Makefile", "makefile"},
+		{"Uppercase extension", "README.MD", "markdown"},
+		{"Unknown extension", "schema.proto", "proto"},
+		{"Unknown file without extension", "LICENSE", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, FenceLanguage(tt.path))
+		})
+	}
+}
+
+func TestLanguageFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		lang     string
+		expected string
+	}{
+		{"Same name", "python", "python"},
+		{"Shell", "shell", "bash"},
+		{"Docker", "docker", "dockerfile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, LanguageFence(tt.lang))
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	StatusAdded    = "added"
+	StatusModified = "modified"
+	StatusDeleted  = "deleted"
+)
+
+// Change is a file touched by a ChangeSet, with its path relative to the
+// repository directory it was opened at.
+type Change struct {
+	Path   string
+	Status string
+}
+
+// ChangeSet selects which changes to export. Exactly one field should be set.
+type ChangeSet struct {
+	// Since compares the working tree against a ref. Untracked files that
+	// are not ignored are added.
+	Since string
+	// Staged compares the index against HEAD.
+	Staged bool
+	// Commit is the change introduced by a single commit.
+	Commit string
+}
+
+// object returns the git object name a changed file is read from, or "" when
+// it is read from the working tree. Paths starting with ./ are resolved
+// relative to the directory git runs in.
+func (cs ChangeSet) object(path string) string {
+	switch {
+	case cs.Staged:
+		return ":./" + path
+	case cs.Commit != "":
+		return cs.Commit + ":./" + path
+	default:
+		return ""
+	}
+}
+
+func (cs ChangeSet) String() string {
+	switch {
+	case cs.Staged:
+		return "staged changes"
+	case cs.Commit != "":
+		return fmt.Sprintf("commit %s", cs.Commit)
+	default:
+		return fmt.Sprintf("changes since %s", cs.Since)
+	}
+}
+
+// File is a regular file in the tree of a revision.
+type File struct {
+	Path   string
+	Object string
+	Size   int64
+}
+
+// Repository runs git commands in a directory of a repository. Paths are
+// relative to that directory and limited to it, as with `git diff --relative`.
+type Repository struct {
+	dir string
+
+	objectsOnce   sync.Once
+	objectsReader *ObjectReader
+}
+
+func Open(dir string) (*Repository, error) {
+	repo := &Repository{dir: dir}
+	if _, err := repo.run("rev-parse", "--git-dir"); err != nil {
+		return nil, fmt.Errorf("not a git repository: %s: %w", dir, err)
+	}
+	return repo, nil
+}
+
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// ReadFile reads a changed file as it is in the change set: from the working
+// tree for Since, the index for Staged and the commit for Commit.
+func (r *Repository) ReadFile(cs ChangeSet, path string) ([]byte, error) {
+	object := cs.object(path)
+	if object == "" {
+		return os.ReadFile(filepath.Join(r.dir, filepath.FromSlash(path)))
+	}
+	return r.objects().Read(object)
+}
+
+// ResolveRevision returns the full id of the commit a revision points to.
+func (r *Repository) ResolveRevision(rev string) (string, error) {
+	out, err := r.run("rev-parse", "--verify", "--quiet", "--end-of-options", rev+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("unknown revision %q: %w", rev, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ListFiles lists the regular files in the tree of a revision, as
+// `git ls-tree` does from the repository directory. Symlinks and submodules
+// are skipped.
+func (r *Repository) ListFiles(rev string) ([]File, error) {
+	out, err := r.run("ls-tree", "-r", "-z", "-l", "--end-of-options", rev)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, entry := range strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00") {
+		if entry == "" {
+			continue
+		}
+
+		// <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected git output: %q", entry)
+		}
+		if fields[1] != "blob" || fields[0] == "120000" {
+			continue
+		}
+
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected git output: %q", entry)
+		}
+		files = append(files, File{Path: path, Object: fields[2], Size: size})
+	}
+	return files, nil
+}
+
+// ReadObject reads a blob by object name, e.g. an id returned by ListFiles.
+func (r *Repository) ReadObject(object string) ([]byte, error) {
+	return r.objects().Read(object)
+}
+
+// Changes lists the files touched by the change set, sorted by path. Renames
+// are reported as a deletion and an addition.
+func (r *Repository) Changes(cs ChangeSet) ([]Change, error) {
+	out, err := r.run(r.diffArgs(cs, "--name-status", "-z")...)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := splitNUL(out)
+	if len(fields)%2 != 0 {
+		return nil, fmt.Errorf("unexpected git output: %q", out)
+	}
+
+	changes := make([]Change, 0, len(fields)/2)
+	for i := 0; i < len(fields); i += 2 {
+		changes = append(changes, Change{Path: fields[i+1], Status: statusName(fields[i])})
+	}
+
+	if cs.Staged || cs.Commit != "" {
+		return changes, nil
+	}
+	untracked, err := r.untracked()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range untracked {
+		changes = append(changes, Change{Path: path, Status: StatusAdded})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// untracked lists the files of the working tree that are neither tracked
+// nor ignored. They have no diff.
+func (r *Repository) untracked() ([]string, error) {
+	out, err := r.run("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// Diffs returns the unified diff of every changed file, keyed by path.
+func (r *Repository) Diffs(cs ChangeSet) (map[string][]byte, error) {
+	out, err := r.run(r.diffArgs(cs, "-p")...)
+	if err != nil {
+		return nil, err
+	}
+	return splitDiff(out), nil
+}
+
+func (r *Repository) diffArgs(cs ChangeSet, extra ...string) []string {
+	var args []string
+	switch {
+	case cs.Staged:
+		args = []string{"diff", "--cached"}
+	case cs.Commit != "":
+		args = []string{"diff-tree", "-r", "--no-commit-id", "--root"}
+	default:
+		args = []string{"diff"}
+	}
+
+	args = append(args, "--relative", "--no-renames", "--no-color", "--no-ext-diff")
+	args = append(args, extra...)
+
+	switch {
+	case cs.Commit != "":
+		args = append(args, cs.Commit)
+	case cs.Since != "":
+		args = append(args, cs.Since)
+	}
+	return append(args, "--")
+}
+
+func (r *Repository) run(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-c", "core.quotePath=false"}, args...)...)
+	cmd.Dir = r.dir
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return nil, fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// Close stops the background git process used to read objects, if any.
+func (r *Repository) Close() error {
+	if r.objectsReader == nil {
+		return nil
+	}
+	return r.objectsReader.Close()
+}
+
+func (r *Repository) objects() *ObjectReader {
+	r.objectsOnce.Do(func() {
+		r.objectsReader = NewObjectReader(r.dir)
+	})
+	return r.objectsReader
+}
+
+// splitNUL splits NUL-terminated fields.
+func splitNUL(out []byte) []string {
+	if len(out) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(out), "\x00"), "\x00")
+}
+
+func statusName(status string) string {
+	switch status[0] {
+	case 'A':
+		return StatusAdded
+	case 'D':
+		return StatusDeleted
+	default:
+		return StatusModified
+	}
+}
+
+// splitDiff splits the output of `git diff -p` into per-file diffs.
+func splitDiff(out []byte) map[string][]byte {
+	diffs := make(map[string][]byte)
+
+	var block []string
+	flush := func() {
+		if path := diffPath(block); path != "" {
+			diffs[path] = []byte(strings.Join(block, "\n"))
+		}
+		block = nil
+	}
+
+	for _, line := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+		}
+		block = append(block, line)
+	}
+	flush()
+
+	return diffs
+}
+
+// diffPath reads the file a diff block belongs to from its ---/+++ lines,
+// falling back to the "diff --git a/x b/x" line for diffs without content.
+func diffPath(block []string) string {
+	if len(block) == 0 || !strings.HasPrefix(block[0], "diff --git ") {
+		return ""
+	}
+
+	var oldPath, newPath string
+	for _, line := range block[1:] {
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+		if name, ok := strings.CutPrefix(line, "--- "); ok {
+			oldPath = headerPath(name, "a/")
+		}
+		if name, ok := strings.CutPrefix(line, "+++ "); ok {
+			newPath = headerPath(name, "b/")
+		}
+	}
+
+	switch {
+	case newPath != "":
+		return newPath
+	case oldPath != "":
+		return oldPath
+	}
+
+	// Without ---/+++ lines, both paths of "diff --git a/x b/x" are the same.
+	names := strings.TrimPrefix(block[0], "diff --git ")
+	if strings.HasSuffix(names, `"`) {
+		if i := strings.LastIndex(names, ` "b/`); i >= 0 {
+			return headerPath(names[i+1:], "b/")
+		}
+		return ""
+	}
+	if n := (len(names) - len(" a/b/")) / 2; n > 0 && names[:2+n] == "a/"+names[len(names)-n:] {
+		return names[len(names)-n:]
+	}
+	return ""
+}
+
+// headerPath returns the path of a ---/+++ line without its prefix, the tab
+// git ends it with when it has spaces and the quotes around it when it has
+// special characters, or "" for /dev/null.
+func headerPath(name, prefix string) string {
+	name = strings.TrimSuffix(name, "\t")
+	if strings.HasPrefix(name, `"`) {
+		unquoted, err := strconv.Unquote(name)
+		if err != nil {
+			return ""
+		}
+		name = unquoted
+	}
+	path, ok := strings.CutPrefix(name, prefix)
+	if !ok {
+		return ""
+	}
+	return path
+}
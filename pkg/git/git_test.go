@@ -0,0 +1,249 @@
+// File: pkg/git/git_test.go
+
+package git
+
+import (
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRepo creates a repository with one commit containing a.go, b.go and
+// "my file.go", then modifies a.go and "my file.go", deletes b.go, stages a new c.go and creates an untracked
+// d.go next to an ignored e.go.
+func newTestRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	gitCmd := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+	write := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	gitCmd("init", "-q")
+	write("a.go", "package a\n")
+	write("b.go", "package b\n")
+	write("my file.go", "package my\n")
+	gitCmd("add", ".")
+	gitCmd("commit", "-q", "-m", "initial")
+
+	write("a.go", "package a\n\nvar A = 1\n")
+	write("my file.go", "package my\n\nvar My = 1\n")
+	gitCmd("rm", "-q", "b.go")
+	write("c.go", "package c\n")
+	gitCmd("add", "c.go")
+	write("d.go", "package d\n")
+	write("e.go", "package e\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "info", "exclude"), []byte("e.go\n"), 0644))
+
+	return dir
+}
+
+func TestChanges(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	tests := []struct {
+		name      string
+		changeSet ChangeSet
+		expected  []Change
+	}{
+		{
+			name:      "Since HEAD",
+			changeSet: ChangeSet{Since: "HEAD"},
+			expected: []Change{
+				{Path: "a.go", Status: StatusModified},
+				{Path: "b.go", Status: StatusDeleted},
+				{Path: "c.go", Status: StatusAdded},
+				{Path: "d.go", Status: StatusAdded},
+				{Path: "my file.go", Status: StatusModified},
+			},
+		},
+		{
+			name:      "Staged",
+			changeSet: ChangeSet{Staged: true},
+			expected: []Change{
+				{Path: "b.go", Status: StatusDeleted},
+				{Path: "c.go", Status: StatusAdded},
+			},
+		},
+		{
+			name:      "Commit",
+			changeSet: ChangeSet{Commit: "HEAD"},
+			expected: []Change{
+				{Path: "a.go", Status: StatusAdded},
+				{Path: "b.go", Status: StatusAdded},
+				{Path: "my file.go", Status: StatusAdded},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := repo.Changes(tt.changeSet)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, changes)
+		})
+	}
+}
+
+func TestDiffsAndReadFile(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	diffs, err := repo.Diffs(ChangeSet{Since: "HEAD"})
+	require.NoError(t, err)
+	assert.Len(t, diffs, 4)
+	assert.Contains(t, string(diffs["a.go"]), "+var A = 1")
+	assert.Contains(t, string(diffs["my file.go"]), "+var My = 1")
+	assert.Contains(t, string(diffs["b.go"]), "-package b")
+
+	content, err := repo.ReadFile(ChangeSet{Since: "HEAD"}, "a.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\nvar A = 1\n", string(content))
+
+	content, err = repo.ReadFile(ChangeSet{Commit: "HEAD"}, "a.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+
+	content, err = repo.ReadFile(ChangeSet{Staged: true}, "c.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package c\n", string(content))
+
+	_, err = repo.ReadFile(ChangeSet{Commit: "HEAD"}, "missing.go")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestDiffPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		block    []string
+		expected string
+	}{
+		{"Modified", []string{"diff --git a/a.go b/a.go", "--- a/a.go", "+++ b/a.go", "@@ -1 +1 @@"}, "a.go"},
+		{"Added", []string{"diff --git a/c.go b/c.go", "--- /dev/null", "+++ b/c.go"}, "c.go"},
+		{"Deleted", []string{"diff --git a/b.go b/b.go", "--- a/b.go", "+++ /dev/null"}, "b.go"},
+		{"Space", []string{"diff --git a/c/my file.go b/c/my file.go", "--- /dev/null", "+++ b/c/my file.go\t"}, "c/my file.go"},
+		{"Quoted", []string{`diff --git "a/tab\there.go" "b/tab\there.go"`, "--- /dev/null", `+++ "b/tab\there.go"`}, "tab\there.go"},
+		{"No content", []string{"diff --git a/my file.bin b/my file.bin", "old mode 100644", "new mode 100755"}, "my file.bin"},
+		{"No content, quoted", []string{`diff --git "a/q\"x" "b/q\"x"`, "old mode 100644", "new mode 100755"}, `q"x`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, diffPath(tt.block))
+		})
+	}
+}
+
+func TestOpenNotARepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	_, err := Open(t.TempDir())
+	assert.Error(t, err)
+}
+
+func TestListFilesAndReadObject(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	commit, err := repo.ResolveRevision("HEAD")
+	require.NoError(t, err)
+	assert.Len(t, commit, 40)
+
+	_, err = repo.ResolveRevision("no-such-branch")
+	assert.Error(t, err)
+
+	// The revision is read from the object database, so the working tree
+	// changes made by newTestRepo do not show up.
+	files, err := repo.ListFiles("HEAD")
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	assert.Equal(t, "a.go", files[0].Path)
+	assert.Equal(t, "b.go", files[1].Path)
+	assert.Equal(t, "my file.go", files[2].Path)
+	assert.Equal(t, int64(len("package a\n")), files[0].Size)
+
+	content, err := repo.ReadObject(files[0].Object)
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n", string(content))
+}
+
+func TestTreeFS(t *testing.T) {
+	dir := newTestRepo(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "pkg", "util"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "util", "util.go"), []byte("package util\n"), 0644))
+	for _, args := range [][]string{{"add", "."}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "second"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	fsys, err := repo.TreeFS("HEAD")
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, "a.go", "c.go", "pkg/util/util.go"))
+
+	content, err := fs.ReadFile(fsys, "pkg/util/util.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package util\n", string(content))
+
+	_, err = fs.Stat(fsys, "b.go")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestChangeSetFS(t *testing.T) {
+	dir := newTestRepo(t)
+	repo, err := Open(dir)
+	require.NoError(t, err)
+	defer repo.Close()
+
+	tests := []struct {
+		name      string
+		changeSet ChangeSet
+		path      string
+		expected  string
+	}{
+		{"Since", ChangeSet{Since: "HEAD"}, "a.go", "package a\n\nvar A = 1\n"},
+		{"Staged", ChangeSet{Staged: true}, "c.go", "package c\n"},
+		{"Commit", ChangeSet{Commit: "HEAD"}, "a.go", "package a\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := repo.ChangeSetFS(tt.changeSet)
+			content, err := fs.ReadFile(fsys, tt.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+
+			_, err = fs.ReadFile(fsys, "missing.go")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ObjectReader reads blobs from the object database through a single
+// long-running `git cat-file --batch` process. It is safe for concurrent use.
+type ObjectReader struct {
+	mu     sync.Mutex
+	dir    string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+func NewObjectReader(dir string) *ObjectReader {
+	return &ObjectReader{dir: dir}
+}
+
+// Read returns the contents of the blob named by object, e.g. "HEAD:./main.go".
+// Missing objects return an error wrapping os.ErrNotExist.
+func (o *ObjectReader) Read(object string) ([]byte, error) {
+	if strings.ContainsAny(object, "\n") {
+		return nil, fmt.Errorf("invalid object name: %q", object)
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cmd == nil {
+		if err := o.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := fmt.Fprintf(o.stdin, "%s\n", object); err != nil {
+		return nil, fmt.Errorf("failed to request %s: %w", object, err)
+	}
+
+	header, err := o.stdout.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", object, err)
+	}
+
+	fields := strings.Fields(header)
+	if len(fields) == 2 && (fields[1] == "missing" || fields[1] == "ambiguous") {
+		return nil, fmt.Errorf("%s: %w", object, os.ErrNotExist)
+	}
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("unexpected cat-file header for %s: %q", object, header)
+	}
+
+	size, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("unexpected cat-file header for %s: %q", object, header)
+	}
+
+	// The object is followed by a newline.
+	data := make([]byte, size+1)
+	if _, err := io.ReadFull(o.stdout, data); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", object, err)
+	}
+
+	if fields[1] != "blob" {
+		return nil, fmt.Errorf("%s is a %s, not a file", object, fields[1])
+	}
+	return data[:size], nil
+}
+
+func (o *ObjectReader) start() error {
+	cmd := exec.Command("git", "cat-file", "--batch")
+	cmd.Dir = o.dir
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start git cat-file: %w", err)
+	}
+
+	o.cmd = cmd
+	o.stdin = stdin
+	o.stdout = bufio.NewReader(stdout)
+	return nil
+}
+
+func (o *ObjectReader) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.cmd == nil {
+		return nil
+	}
+
+	o.stdin.Close()
+	err := o.cmd.Wait()
+	o.cmd = nil
+	return err
+}
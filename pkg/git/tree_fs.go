@@ -0,0 +1,204 @@
+// File: pkg/git/tree_fs.go
+
+package git
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"time"
+)
+
+// treeFS is the tree of a revision as a read-only filesystem. Its files are
+// read from the object database when they are opened.
+type treeFS struct {
+	repo  *Repository
+	files map[string]File
+	dirs  map[string][]fs.DirEntry
+}
+
+// TreeFS returns the tree of a revision as a read-only filesystem rooted at
+// the repository directory, holding the files listed by ListFiles. Its files
+// have no modification time.
+func (r *Repository) TreeFS(rev string) (fs.FS, error) {
+	files, err := r.ListFiles(rev)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &treeFS{
+		repo:  r,
+		files: make(map[string]File, len(files)),
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+	for _, file := range files {
+		t.files[file.Path] = file
+
+		dir := path.Dir(file.Path)
+		t.addDir(dir)
+		t.dirs[dir] = append(t.dirs[dir], treeInfo{name: path.Base(file.Path), size: file.Size})
+	}
+	for _, entries := range t.dirs {
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].Name() < entries[j].Name()
+		})
+	}
+	return t, nil
+}
+
+// ChangeSetFS returns the files of a change set as a read-only filesystem
+// rooted at the repository directory: the working tree for Since, and
+// otherwise the files as ReadFile reads them, which cannot be listed and
+// have no modification time.
+func (r *Repository) ChangeSetFS(cs ChangeSet) fs.FS {
+	if cs.object("") == "" {
+		return os.DirFS(r.dir)
+	}
+	return changeSetFS{repo: r, cs: cs}
+}
+
+type changeSetFS struct {
+	repo *Repository
+	cs   ChangeSet
+}
+
+func (c changeSetFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	content, err := c.repo.ReadFile(c.cs, name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &treeFile{info: treeInfo{name: path.Base(name), size: int64(len(content))}, Reader: bytes.NewReader(content)}, nil
+}
+
+func (t *treeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if entries, ok := t.dirs[name]; ok {
+		return &treeDir{info: treeInfo{name: path.Base(name), dir: true}, path: name, entries: entries}, nil
+	}
+
+	content, err := t.ReadFile(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.Unwrap(err)}
+	}
+	return &treeFile{info: t.info(name), Reader: bytes.NewReader(content)}, nil
+}
+
+func (t *treeFS) ReadFile(name string) ([]byte, error) {
+	file, ok := t.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	content, err := t.repo.ReadObject(file.Object)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return content, nil
+}
+
+func (t *treeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, ok := t.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]fs.DirEntry(nil), entries...), nil
+}
+
+// Stat returns a file's info without reading it.
+func (t *treeFS) Stat(name string) (fs.FileInfo, error) {
+	if _, ok := t.dirs[name]; ok {
+		return treeInfo{name: path.Base(name), dir: true}, nil
+	}
+	if _, ok := t.files[name]; ok {
+		return t.info(name), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (t *treeFS) info(name string) treeInfo {
+	return treeInfo{name: path.Base(name), size: t.files[name].Size}
+}
+
+// addDir adds a directory, and the parents it is missing, each as an entry
+// of its parent.
+func (t *treeFS) addDir(dir string) {
+	if _, ok := t.dirs[dir]; ok {
+		return
+	}
+	t.dirs[dir] = nil
+
+	parent := path.Dir(dir)
+	t.addDir(parent)
+	t.dirs[parent] = append(t.dirs[parent], treeInfo{name: path.Base(dir), dir: true})
+}
+
+// treeInfo describes a file or directory of a tree, as both its
+// fs.FileInfo and its fs.DirEntry.
+type treeInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i treeInfo) Name() string       { return i.name }
+func (i treeInfo) Size() int64        { return i.size }
+func (i treeInfo) ModTime() time.Time { return time.Time{} }
+func (i treeInfo) IsDir() bool        { return i.dir }
+func (i treeInfo) Sys() any           { return nil }
+
+func (i treeInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+func (i treeInfo) Type() fs.FileMode {
+	return i.Mode().Type()
+}
+
+func (i treeInfo) Info() (fs.FileInfo, error) {
+	return i, nil
+}
+
+type treeFile struct {
+	info treeInfo
+	*bytes.Reader
+}
+
+func (f *treeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *treeFile) Close() error               { return nil }
+
+type treeDir struct {
+	info    treeInfo
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *treeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *treeDir) Close() error               { return nil }
+
+func (d *treeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+func (d *treeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries := d.entries[d.offset:]
+	if n > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		entries = entries[:min(n, len(entries))]
+	}
+	d.offset += len(entries)
+	return append([]fs.DirEntry(nil), entries...), nil
+}
@@ -1,41 +1,125 @@
 package gitignore
 
 import (
+	"bytes"
+	"io/fs"
+	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/denormal/go-gitignore"
-	"github.com/rs/zerolog/log"
 )
 
+// GitIgnore matches paths against the .gitignore files of a tree and its
+// .git/info/exclude file, as git does: a path in an ignored directory is
+// ignored, and the patterns of deeper .gitignore files take precedence.
 type GitIgnore struct {
-	ignoreMatcher gitignore.GitIgnore
-	rootDir       string
+	fsys    fs.FS
+	rootDir string
+	exclude gitignore.GitIgnore
+
+	mu sync.Mutex
+	// files caches the parsed .gitignore file of each directory, or nil
+	// for directories without one.
+	files map[string]gitignore.GitIgnore
 }
 
 func NewGitIgnore(rootDir string) (*GitIgnore, error) {
-	ignoreMatcher, err := gitignore.NewRepository(rootDir)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to parse .gitignore files")
+	return NewGitIgnoreFS(os.DirFS(rootDir), rootDir)
+}
+
+// NewGitIgnoreFS reads the .gitignore files of fsys, whose root is rootDir.
+// rootDir is only used to match absolute paths, and need not exist.
+func NewGitIgnoreFS(fsys fs.FS, rootDir string) (*GitIgnore, error) {
+	if _, err := fs.Stat(fsys, "."); err != nil {
 		return nil, err
 	}
 
-	return &GitIgnore{
-		ignoreMatcher: ignoreMatcher,
-		rootDir:       rootDir,
-	}, nil
+	g := &GitIgnore{
+		fsys:    fsys,
+		rootDir: rootDir,
+		files:   make(map[string]gitignore.GitIgnore),
+	}
+	g.exclude = g.parse(".git/info/exclude")
+	return g, nil
 }
 
-func (g *GitIgnore) ShouldIgnore(path string) bool {
-	if g.ignoreMatcher == nil {
+// ShouldIgnore reports whether a path, relative to the root or absolute, is
+// ignored. Paths that do not exist are matched as files.
+func (g *GitIgnore) ShouldIgnore(p string) bool {
+	relPath := p
+	if filepath.IsAbs(p) {
+		var err error
+		relPath, err = filepath.Rel(g.rootDir, p)
+		if err != nil {
+			return false
+		}
+	}
+	relPath = filepath.ToSlash(filepath.Clean(relPath))
+	if relPath == "." || relPath == ".." || strings.HasPrefix(relPath, "../") {
 		return false
 	}
 
-	relPath, err := filepath.Rel(g.rootDir, path)
-	if err != nil {
-		log.Error().Err(err).Str("path", path).Msg("Failed to get relative path")
-		return false
+	isDir := false
+	if info, err := fs.Stat(g.fsys, relPath); err == nil {
+		isDir = info.IsDir()
 	}
 
-	match := g.ignoreMatcher.Match(relPath)
+	match := g.match(relPath, isDir)
 	return match != nil && match.Ignore()
 }
+
+// match returns the pattern matching a path, if any.
+func (g *GitIgnore) match(p string, isDir bool) gitignore.Match {
+	dir, local := path.Split(p)
+	dir = path.Clean(dir)
+	if dir != "." {
+		if match := g.match(dir, true); match != nil && match.Ignore() {
+			return match
+		}
+	}
+
+	// The .gitignore files are considered from the path's directory up.
+	for {
+		if ignore := g.file(dir); ignore != nil {
+			if match := ignore.Relative(local, isDir); match != nil {
+				return match
+			}
+		}
+		if dir == "." {
+			break
+		}
+		parent, name := path.Split(dir)
+		dir = path.Clean(parent)
+		local = name + "/" + local
+	}
+
+	if g.exclude != nil {
+		return g.exclude.Relative(p, isDir)
+	}
+	return nil
+}
+
+// file returns the parsed .gitignore file of a directory.
+func (g *GitIgnore) file(dir string) gitignore.GitIgnore {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ignore, ok := g.files[dir]
+	if !ok {
+		ignore = g.parse(path.Join(dir, ".gitignore"))
+		g.files[dir] = ignore
+	}
+	return ignore
+}
+
+// parse parses an ignore file, returning nil if it cannot be read.
+func (g *GitIgnore) parse(name string) gitignore.GitIgnore {
+	content, err := fs.ReadFile(g.fsys, name)
+	if err != nil {
+		return nil
+	}
+	return gitignore.New(bytes.NewReader(content), path.Dir(name), nil)
+}
@@ -0,0 +1,52 @@
+// File: pkg/gitignore/gitignore_test.go
+
+package gitignore
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldIgnore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".gitignore":        {Data: []byte("*.log\nbuild/\n")},
+		".git/info/exclude": {Data: []byte("secret.txt\n")},
+		"main.go":           {Data: []byte("package main\n")},
+		"app.log":           {Data: []byte("log\n")},
+		"build/out.go":      {Data: []byte("package build\n")},
+		"docs/.gitignore":   {Data: []byte("!keep.log\n*.md\n")},
+		"docs/keep.log":     {Data: []byte("log\n")},
+		"docs/guide.md":     {Data: []byte("# Guide\n")},
+		"secret.txt":        {Data: []byte("secret\n")},
+	}
+	rootDir := filepath.Join(string(filepath.Separator), "repo")
+	g, err := NewGitIgnoreFS(fsys, rootDir)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path     string
+		expected bool
+	}{
+		{"main.go", false},
+		{"app.log", true},
+		{"build", true},
+		{"build/out.go", true},
+		{"docs/keep.log", false},
+		{"docs/guide.md", true},
+		{"guide.md", false},
+		{"secret.txt", true},
+		{"missing.log", true},
+		{filepath.Join(rootDir, "app.log"), true},
+		{filepath.Join(rootDir, "main.go"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, g.ShouldIgnore(tt.path))
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package languagedetector
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/daemonp/gogpt/pkg/fileutils"
+)
+
+// filenames maps file names that identify a language on their own.
+var filenames = map[string]string{
+	"Dockerfile":      "docker",
+	"Containerfile":   "docker",
+	"Makefile":        "make",
+	"makefile":        "make",
+	"GNUmakefile":     "make",
+	"Jenkinsfile":     "groovy",
+	"BUILD":           "starlark",
+	"BUILD.bazel":     "starlark",
+	"WORKSPACE":       "starlark",
+	"WORKSPACE.bazel": "starlark",
+	"MODULE.bazel":    "starlark",
+	"Rakefile":        "ruby",
+	"Gemfile":         "ruby",
+	"Vagrantfile":     "ruby",
+	"Podfile":         "ruby",
+	".bashrc":         "shell",
+	".bash_profile":   "shell",
+	".zshrc":          "shell",
+	".profile":        "shell",
+}
+
+// interpreters maps shebang interpreters, without version suffixes, to
+// languages.
+var interpreters = map[string]string{
+	"python":     "python",
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"ksh":        "shell",
+	"dash":       "shell",
+	"ash":        "shell",
+	"node":       "js",
+	"nodejs":     "js",
+	"deno":       "ts",
+	"ts-node":    "ts",
+	"ruby":       "ruby",
+	"perl":       "perl",
+	"php":        "php",
+	"pwsh":       "powershell",
+	"powershell": "powershell",
+	"groovy":     "groovy",
+	"swift":      "swift",
+	"scala":      "scala",
+}
+
+// modeNames maps Vim filetypes and Emacs modes that differ from gogpt's
+// language names.
+var modeNames = map[string]string{
+	"sh":         "shell",
+	"bash":       "shell",
+	"zsh":        "shell",
+	"javascript": "js",
+	"typescript": "ts",
+	"c++":        "cpp",
+	"cs":         "csharp",
+	"makefile":   "make",
+	"dockerfile": "docker",
+	"bzl":        "starlark",
+	"dosini":     "config",
+	"conf":       "config",
+	"ps1":        "powershell",
+	"md":         "markdown",
+	"python3":    "python",
+}
+
+// heuristics resolve extensions shared by several languages from content.
+var heuristics = map[string]func(content []byte) string{
+	"h":  detectHeader,
+	"ts": detectTS,
+}
+
+var (
+	vimModeline   = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex)(?:[<=>]?\d+)?:.*?\b(?:ft|filetype|syntax)=([\w+-]+)`)
+	emacsModeline = regexp.MustCompile(`-\*-(.+?)-\*-`)
+	emacsMode     = regexp.MustCompile(`(?i)mode\s*:\s*([\w+-]+)`)
+	cppMarkers    = regexp.MustCompile(`(?m)^\s*(?:template\s*<|namespace\s+\w+|class\s+\w+\s*(?:[:{]|$)|(?:public|private|protected)\s*:|#include\s*<(?:iostream|string|vector|memory|map|algorithm)>)|\bstd::`)
+)
+
+// modelineLines is how many lines at each end of a file are searched for a
+// modeline, as in Vim.
+const modelineLines = 5
+
+// Detect returns the language of a file, or "" if it is not recognized. It
+// looks at modelines, the file name, the shebang, the extension and, for
+// extensions shared by several languages, the content, in that order.
+func Detect(path string, content []byte) string {
+	if lang := detectModeline(content); lang != "" {
+		return lang
+	}
+	if lang, ok := filenames[filepath.Base(path)]; ok {
+		return lang
+	}
+	if lang := detectShebang(content); lang != "" {
+		return lang
+	}
+
+	ext := strings.ToLower(fileutils.GetFileExtension(path))
+	if heuristic, ok := heuristics[ext]; ok {
+		return heuristic(content)
+	}
+	return languageForExtension(ext)
+}
+
+// DetectByPath returns the language a path alone points to, and whether that
+// is certain. It is not when the file has no extension or one shared by
+// several languages; Detect needs the content then. Files with an unknown
+// extension are certainly not in any language.
+func DetectByPath(path string) (string, bool) {
+	base := filepath.Base(path)
+	if lang, ok := filenames[base]; ok {
+		return lang, true
+	}
+	if filepath.Ext(base) == "" {
+		return languageForExtension(base), false
+	}
+
+	ext := strings.ToLower(fileutils.GetFileExtension(path))
+	if _, ok := heuristics[ext]; ok {
+		return languageForExtension(ext), false
+	}
+	return languageForExtension(ext), true
+}
+
+// languageNames lists the known languages in a fixed order, so an extension
+// claimed by more than one always resolves the same way.
+var languageNames = sync.OnceValue(func() []string {
+	names := make([]string, 0, len(fileutils.LanguageExtensions))
+	for lang := range fileutils.LanguageExtensions {
+		names = append(names, lang)
+	}
+	sort.Strings(names)
+	return names
+})
+
+func languageForExtension(ext string) string {
+	for _, lang := range languageNames() {
+		if fileutils.IsLanguageFile(lang, ext) {
+			return lang
+		}
+	}
+	return ""
+}
+
+func detectShebang(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+
+	line, _, _ := bytes.Cut(content[2:], []byte("\n"))
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env's own options and variable assignments.
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = filepath.Base(field)
+				break
+			}
+		}
+	}
+
+	// python3.11 -> python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return interpreters[interpreter]
+}
+
+func detectModeline(content []byte) string {
+	lines := bytes.Split(content, []byte("\n"))
+	if len(lines) > 2*modelineLines {
+		lines = append(lines[:modelineLines:modelineLines], lines[len(lines)-modelineLines:]...)
+	}
+
+	for _, line := range lines {
+		if m := vimModeline.FindSubmatch(line); m != nil {
+			if lang := modeLanguage(string(m[1])); lang != "" {
+				return lang
+			}
+		}
+		if m := emacsModeline.FindSubmatch(line); m != nil {
+			mode := strings.TrimSpace(string(m[1]))
+			if sub := emacsMode.FindStringSubmatch(mode); sub != nil {
+				mode = sub[1]
+			} else if strings.Contains(mode, ":") {
+				continue
+			}
+			if lang := modeLanguage(mode); lang != "" {
+				return lang
+			}
+		}
+	}
+	return ""
+}
+
+func modeLanguage(mode string) string {
+	mode = strings.ToLower(mode)
+	if lang, ok := modeNames[mode]; ok {
+		return lang
+	}
+	if _, ok := fileutils.LanguageExtensions[mode]; ok {
+		return mode
+	}
+	return ""
+}
+
+// detectHeader tells C++ headers from C ones.
+func detectHeader(content []byte) string {
+	if cppMarkers.Match(content) {
+		return "cpp"
+	}
+	return "c"
+}
+
+// detectTS tells TypeScript from Qt translation files.
+func detectTS(content []byte) string {
+	trimmed := bytes.TrimSpace(content)
+	if bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<TS")) {
+		return "xml"
+	}
+	return "ts"
+}
@@ -0,0 +1,68 @@
+// File: pkg/languagedetector/detect_test.go
+
+package languagedetector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name     string
+		path     string
+		content  string
+		expected string
+	}{
+		{"Extension", "main.go", "package main\n", "go"},
+		{"Unknown extension", "notes.txt", "hello\n", ""},
+		{"Filename", "ci/Jenkinsfile", "pipeline {}\n", "groovy"},
+		{"Bazel build file", "pkg/BUILD.bazel", "go_library()\n", "starlark"},
+		{"Bare Bazel build file", "BUILD", "go_library()\n", "starlark"},
+		{"Shebang with env", "bin/deploy", "#!/usr/bin/env python3\nprint('hi')\n", "python"},
+		{"Shebang with env options", "bin/run", "#!/usr/bin/env -S node --no-warnings\n", "js"},
+		{"Shebang with path", "bin/build", "#!/bin/bash\nset -e\n", "shell"},
+		{"Unknown shebang", "bin/tool", "#!/usr/bin/env unknown\n", ""},
+		{"No extension or shebang", "LICENSE", "MIT License\n", ""},
+		{"Vim modeline", "scripts/setup", "# vim: set ft=sh:\necho hi\n", "shell"},
+		{"Vim modeline overrides extension", "hooks.conf", "# vim: filetype=python\n", "python"},
+		{"Emacs modeline", "tool", "# -*- mode: ruby; coding: utf-8 -*-\n", "ruby"},
+		{"Short Emacs modeline", "tool", "# -*- python -*-\n", "python"},
+		{"Emacs modeline without mode", "tool", "# -*- coding: utf-8 -*-\n", ""},
+		{"C header", "lib/list.h", "#include <stdio.h>\nstruct list { int n; };\n", "c"},
+		{"C++ header", "lib/list.h", "#include <vector>\nnamespace lib {\nclass List {\n};\n}\n", "cpp"},
+		{"C++ header using std", "lib/util.h", "inline int size(const std::string &s);\n", "cpp"},
+		{"TypeScript", "app.ts", "export const x = 1;\n", "ts"},
+		{"Qt translation", "app_de.ts", "<?xml version=\"1.0\"?>\n<TS version=\"2.1\"></TS>\n", "xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Detect(tt.path, []byte(tt.content)))
+		})
+	}
+}
+
+func TestDetectByPath(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		expected        string
+		expectedCertain bool
+	}{
+		{"Known extension", "main.go", "go", true},
+		{"Unknown extension", "logo.png", "", true},
+		{"Filename", "Dockerfile", "docker", true},
+		{"No extension", "bin/deploy", "", false},
+		{"Ambiguous extension", "list.h", "c", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lang, certain := DetectByPath(tt.path)
+			assert.Equal(t, tt.expected, lang)
+			assert.Equal(t, tt.expectedCertain, certain)
+		})
+	}
+}
@@ -1,42 +1,144 @@
 package languagedetector
 
 import (
-	"os"
-	"path/filepath"
-	"strings"
-
-	"github.com/daemonp/gogpt/pkg/fileutils"
+	"bytes"
+	"io/fs"
 )
 
-func DetectLanguages(dir string) string {
-	languages := make(map[string]bool)
+// DetectLanguages walks fsys and returns statistics for every language found.
+// Paths, relative to the root of fsys, for which ignore returns true are
+// skipped, along with the contents of ignored directories. Tokens are counted
+// with count when it is not nil.
+func DetectLanguages(fsys fs.FS, ignore func(path string) bool, count func(string) int) Stats {
+	return DetectLanguagesInPaths(Files(fsys, ignore), func(path string) ([]byte, error) {
+		return fs.ReadFile(fsys, path)
+	}, count)
+}
+
+// Files returns the paths of the files in fsys, as DetectLanguages walks
+// them, so they can be read some other way.
+func Files(fsys fs.FS, ignore func(path string) bool) []string {
+	var paths []string
 
-	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return err
+			return nil
 		}
 
-		if info.IsDir() {
+		if d.IsDir() {
+			if path != "." && (d.Name() == ".git" || ignore != nil && ignore(path)) {
+				return fs.SkipDir
+			}
 			return nil
 		}
 
-		ext := fileutils.GetFileExtension(path)
-		for lang, extensions := range fileutils.LanguageExtensions {
-			for _, e := range extensions {
-				if strings.EqualFold(e, "."+ext) || strings.EqualFold(e, ext) {
-					languages[lang] = true
-					break
-				}
-			}
+		if ignore == nil || !ignore(path) {
+			paths = append(paths, path)
 		}
 
 		return nil
 	})
+	return paths
+}
+
+// DetectLanguagesInPaths returns statistics for the languages of a list of
+// files, read with read. Files that certainly have no language are not read.
+func DetectLanguagesInPaths(paths []string, read func(path string) ([]byte, error), count func(string) int) Stats {
+	languages := make(map[string]*LanguageStats)
+
+	for _, path := range paths {
+		if lang, certain := DetectByPath(path); certain && lang == "" {
+			continue
+		}
 
-	var detectedLangs []string
-	for lang := range languages {
-		detectedLangs = append(detectedLangs, lang)
+		content, err := read(path)
+		if err != nil {
+			continue
+		}
+
+		lang := Detect(path, content)
+		if lang == "" {
+			continue
+		}
+
+		stats, ok := languages[lang]
+		if !ok {
+			stats = &LanguageStats{Language: lang}
+			languages[lang] = stats
+		}
+		stats.Files++
+		stats.Bytes += int64(len(content))
+		stats.Lines += countLines(content)
+		if count != nil {
+			stats.Tokens += count(string(content))
+		}
 	}
 
-	return strings.Join(detectedLangs, ",")
+	var detected Stats
+	for _, stats := range languages {
+		detected = append(detected, *stats)
+	}
+	return detected.normalize()
+}
+
+// DetectShares returns the languages of files in fsys by their share of
+// bytes, without lines or tokens. Only the files whose language is not
+// certain from their path are read, with read; the size of the others is
+// taken from fs.Stat. keep, when not nil, leaves out the files it returns
+// false for; it is given their content, or nil when they are not read.
+func DetectShares(fsys fs.FS, paths []string, read func(path string) ([]byte, error), keep func(path string, content []byte) bool) Stats {
+	languages := make(map[string]*LanguageStats)
+
+	for _, path := range paths {
+		lang, certain := DetectByPath(path)
+		if certain && lang == "" {
+			continue
+		}
+
+		var size int64
+		if certain {
+			info, err := fs.Stat(fsys, path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			if keep != nil && !keep(path, nil) {
+				continue
+			}
+			size = info.Size()
+		} else {
+			content, err := read(path)
+			if err != nil {
+				continue
+			}
+			if lang = Detect(path, content); lang == "" {
+				continue
+			}
+			if keep != nil && !keep(path, content) {
+				continue
+			}
+			size = int64(len(content))
+		}
+
+		stats, ok := languages[lang]
+		if !ok {
+			stats = &LanguageStats{Language: lang}
+			languages[lang] = stats
+		}
+		stats.Files++
+		stats.Bytes += size
+	}
+
+	var detected Stats
+	for _, stats := range languages {
+		detected = append(detected, *stats)
+	}
+	return detected.normalize()
+}
+
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
 }
@@ -0,0 +1,122 @@
+package languagedetector
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+// LanguageStats describes the files of one language. Percentage is the
+// language's share of the bytes of all detected files.
+type LanguageStats struct {
+	Language   string  `json:"language"`
+	Files      int     `json:"files"`
+	Bytes      int64   `json:"bytes"`
+	Lines      int     `json:"lines"`
+	Tokens     int     `json:"tokens"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Stats lists languages by share, largest first, with ties broken by name.
+type Stats []LanguageStats
+
+// Names returns the languages in order of share.
+func (s Stats) Names() []string {
+	names := make([]string, len(s))
+	for i, stats := range s {
+		names[i] = stats.Language
+	}
+	return names
+}
+
+// Total sums the statistics of every language.
+func (s Stats) Total() LanguageStats {
+	total := LanguageStats{Language: "total"}
+	for _, stats := range s {
+		total.Files += stats.Files
+		total.Bytes += stats.Bytes
+		total.Lines += stats.Lines
+		total.Tokens += stats.Tokens
+	}
+	if len(s) > 0 {
+		total.Percentage = 100
+	}
+	return total
+}
+
+// MergeStats combines the statistics of several sets of files.
+func MergeStats(sets ...Stats) Stats {
+	languages := make(map[string]*LanguageStats)
+	var merged Stats
+	for _, set := range sets {
+		for _, stats := range set {
+			if existing, ok := languages[stats.Language]; ok {
+				existing.Files += stats.Files
+				existing.Bytes += stats.Bytes
+				existing.Lines += stats.Lines
+				existing.Tokens += stats.Tokens
+				continue
+			}
+			stats := stats
+			languages[stats.Language] = &stats
+		}
+	}
+	for _, stats := range languages {
+		merged = append(merged, *stats)
+	}
+	return merged.normalize()
+}
+
+// normalize computes the percentages and sorts by share.
+func (s Stats) normalize() Stats {
+	total := s.Total()
+	for i := range s {
+		if total.Bytes > 0 {
+			s[i].Percentage = float64(s[i].Bytes) * 100 / float64(total.Bytes)
+		}
+	}
+
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].Bytes != s[j].Bytes {
+			return s[i].Bytes > s[j].Bytes
+		}
+		return s[i].Language < s[j].Language
+	})
+	return s
+}
+
+// WriteTable writes the statistics as an aligned table with a total row.
+func (s Stats) WriteTable(w io.Writer) error {
+	rows := append(append(Stats{}, s...), s.Total())
+
+	// Numbers are right-aligned; pad the names so they stay left-aligned.
+	width := len("LANGUAGE")
+	for _, stats := range rows {
+		width = max(width, len(stats.Language))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%-*s\tFILES\tLINES\tBYTES\tTOKENS\tSHARE\t\n", width, "LANGUAGE")
+	for _, stats := range rows {
+		fmt.Fprintf(tw, "%-*s\t%d\t%d\t%d\t%d\t%.1f%%\t\n", width, stats.Language, stats.Files, stats.Lines, stats.Bytes, stats.Tokens, stats.Percentage)
+	}
+	return tw.Flush()
+}
+
+// WriteJSON writes the statistics as a JSON object with a "languages" list
+// and a "total".
+func (s Stats) WriteJSON(w io.Writer) error {
+	languages := s
+	if languages == nil {
+		languages = Stats{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		Languages Stats         `json:"languages"`
+		Total     LanguageStats `json:"total"`
+	}{languages, s.Total()})
+}
@@ -0,0 +1,117 @@
+// File: pkg/languagedetector/stats_test.go
+
+package languagedetector
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectLanguages(t *testing.T) {
+	dir := t.TempDir()
+	testFiles := map[string]string{
+		"main.go":           "package main\n\nfunc main() {}\n",
+		"util.go":           "package main\n",
+		"README.md":         "# Title",
+		"bin/deploy":        "#!/bin/sh\necho deploy\n",
+		"logo.png":          "\x89PNG",
+		"vendor/lib/lib.go": strings.Repeat("package lib\n", 100),
+		".git/config":       "[core]\n",
+	}
+	for path, content := range testFiles {
+		fullPath := filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	ignore := func(path string) bool {
+		return filepath.Base(path) == "vendor"
+	}
+	stats := DetectLanguages(os.DirFS(dir), ignore, func(s string) int { return len(strings.Fields(s)) })
+
+	expected := Stats{
+		{Language: "go", Files: 2, Bytes: 42, Lines: 4, Tokens: 7},
+		{Language: "shell", Files: 1, Bytes: 22, Lines: 2, Tokens: 3},
+		{Language: "markdown", Files: 1, Bytes: 7, Lines: 1, Tokens: 2},
+	}
+	require.Len(t, stats, len(expected))
+	for i := range expected {
+		expected[i].Percentage = float64(expected[i].Bytes) * 100 / 71
+		assert.Equal(t, expected[i], stats[i])
+	}
+	assert.Equal(t, []string{"go", "shell", "markdown"}, stats.Names())
+}
+
+func TestDetectShares(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.go":         {Data: []byte("package main\n")},
+		"gen.go":          {Data: []byte("package gen\n")},
+		"web/app.min.js":  {Data: []byte("x()\n")},
+		"bin/deploy":      {Data: []byte("#!/bin/sh\necho deploy\n")},
+		"bin/run":         {Data: []byte("#!/bin/sh\n")},
+		"notes/README.md": {Data: []byte("# Notes\n")},
+	}
+
+	var read []string
+	stats := DetectShares(fsys, []string{"main.go", "gen.go", "web/app.min.js", "bin/deploy", "bin/run", "notes/README.md"}, func(path string) ([]byte, error) {
+		read = append(read, path)
+		return fs.ReadFile(fsys, path)
+	}, func(path string, content []byte) bool {
+		return path != "gen.go" && path != "bin/run" && !strings.Contains(path, ".min.")
+	})
+
+	assert.Equal(t, Stats{
+		{Language: "shell", Files: 1, Bytes: 22, Percentage: 22 * 100.0 / 43},
+		{Language: "go", Files: 1, Bytes: 13, Percentage: 13 * 100.0 / 43},
+		{Language: "markdown", Files: 1, Bytes: 8, Percentage: 8 * 100.0 / 43},
+	}, stats)
+	// Only the files without an extension need to be read.
+	assert.Equal(t, []string{"bin/deploy", "bin/run"}, read)
+}
+
+func TestMergeStats(t *testing.T) {
+	merged := MergeStats(
+		Stats{{Language: "go", Files: 1, Bytes: 10}, {Language: "yaml", Files: 1, Bytes: 30}},
+		Stats{{Language: "go", Files: 2, Bytes: 40}},
+	)
+
+	assert.Equal(t, Stats{
+		{Language: "go", Files: 3, Bytes: 50, Percentage: 62.5},
+		{Language: "yaml", Files: 1, Bytes: 30, Percentage: 37.5},
+	}, merged)
+}
+
+func TestWriteStats(t *testing.T) {
+	stats := MergeStats(Stats{
+		{Language: "go", Files: 3, Bytes: 300, Lines: 30, Tokens: 90},
+		{Language: "markdown", Files: 1, Bytes: 100, Lines: 10, Tokens: 25},
+	})
+
+	var table bytes.Buffer
+	require.NoError(t, stats.WriteTable(&table))
+	lines := strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, []string{"LANGUAGE", "FILES", "LINES", "BYTES", "TOKENS", "SHARE"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"go", "3", "30", "300", "90", "75.0%"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"total", "4", "40", "400", "115", "100.0%"}, strings.Fields(lines[3]))
+
+	var out bytes.Buffer
+	require.NoError(t, stats.WriteJSON(&out))
+	var doc struct {
+		Languages []LanguageStats `json:"languages"`
+		Total     LanguageStats   `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &doc))
+	assert.Equal(t, []LanguageStats(stats), doc.Languages)
+	assert.Equal(t, 4, doc.Total.Files)
+	assert.Equal(t, 115, doc.Total.Tokens)
+}
@@ -0,0 +1,219 @@
+// File: pkg/minifier/lexer.go
+
+package minifier
+
+import (
+	"bytes"
+	"strings"
+)
+
+// lexer copies source code to out, dropping comments. It tracks output
+// lines by number: touched lines had a comment removed, protected lines
+// start inside a string literal.
+type lexer struct {
+	src    []byte
+	pos    int
+	opts   Options
+	syntax *syntax
+
+	out       bytes.Buffer
+	line      int
+	touched   map[int]bool
+	protected map[int]bool
+	// literals are the source spans of comments and string literals.
+	literals [][2]int
+
+	// lastCode is the last non-space byte of code or a string, used to tell
+	// JavaScript regular expressions from division.
+	lastCode byte
+	// word is the span of the identifier lastCode ended, if any.
+	word [2]int
+
+	// templates holds the brace depth of each JavaScript template literal
+	// whose ${...} substitution is being scanned.
+	templates []int
+	// heredocs are the shell here-documents whose bodies start on the next
+	// line.
+	heredocs []heredoc
+	// scalar is the indentation of the YAML line that opened a block scalar
+	// on the current line, or -1.
+	scalar int
+}
+
+type heredoc struct {
+	delimiter string
+	stripTabs bool
+}
+
+func newLexer(language string, content []byte, opts Options) *lexer {
+	return &lexer{
+		src:       content,
+		opts:      opts,
+		syntax:    syntaxes[language],
+		touched:   make(map[int]bool),
+		protected: make(map[int]bool),
+		scalar:    -1,
+	}
+}
+
+func (l *lexer) run() {
+	for l.pos < len(l.src) {
+		if l.syntax.special != nil && l.syntax.special(l) {
+			continue
+		}
+
+		switch {
+		case l.atLineComment():
+			l.comment(l.lineEnd(l.pos))
+		case l.atBlockComment():
+			open, close := l.syntax.blockComment[0], l.syntax.blockComment[1]
+			end := len(l.src)
+			if i := bytes.Index(l.src[l.pos+len(open):], []byte(close)); i >= 0 {
+				end = l.pos + len(open) + i + len(close)
+			}
+			l.comment(end)
+		case strings.IndexByte(l.syntax.quotes, l.src[l.pos]) >= 0 && (l.syntax.quoteStart == nil || l.syntax.quoteStart(l)):
+			l.quoted(l.pos+1, l.src[l.pos], l.syntax.multilineStrings, l.syntax.rawQuotes)
+		default:
+			l.code(l.pos + 1)
+		}
+	}
+}
+
+func (l *lexer) atLineComment() bool {
+	for _, marker := range l.syntax.lineComments {
+		if bytes.HasPrefix(l.src[l.pos:], []byte(marker)) {
+			return l.syntax.commentStart == nil || l.syntax.commentStart(l)
+		}
+	}
+	return false
+}
+
+func (l *lexer) atBlockComment() bool {
+	return l.syntax.blockComment[0] != "" && bytes.HasPrefix(l.src[l.pos:], []byte(l.syntax.blockComment[0]))
+}
+
+// comment removes the comment from pos to end unless it is kept.
+func (l *lexer) comment(end int) {
+	text := l.src[l.pos:end]
+	l.literals = append(l.literals, [2]int{l.pos, end})
+	keep := !l.opts.StripComments || l.syntax.keep != nil && l.syntax.keep(l.src, l.pos, end)
+	if !keep && l.opts.KeepDocComments && l.syntax.doc != nil {
+		keep = l.syntax.doc(l.src, l.pos, end)
+	}
+	if keep {
+		l.emit(end, false)
+		return
+	}
+
+	l.touched[l.line] = true
+	for _, b := range text {
+		if b == '\n' {
+			l.out.WriteByte('\n')
+			l.line++
+			l.touched[l.line] = true
+		}
+	}
+	l.pos = end
+}
+
+// quoted copies a string literal whose content starts at start and ends at
+// quote. Backslash escapes the next byte unless raw is true. A string that
+// is not multiline ends at the end of its line even without a quote.
+func (l *lexer) quoted(start int, quote byte, multiline bool, raw string) {
+	end := len(l.src)
+	for i := start; i < len(l.src); i++ {
+		b := l.src[i]
+		if b == '\\' && strings.IndexByte(raw, quote) < 0 {
+			i++
+			continue
+		}
+		if b == quote {
+			end = i + 1
+			break
+		}
+		if b == '\n' && !multiline {
+			end = i
+			break
+		}
+	}
+	l.str(end)
+}
+
+// delimited copies a string literal that ends with close, searched for from
+// start, with no escapes.
+func (l *lexer) delimited(start int, close string) {
+	end := len(l.src)
+	if i := bytes.Index(l.src[start:], []byte(close)); i >= 0 {
+		end = start + i + len(close)
+	}
+	l.str(end)
+}
+
+// str copies a string literal up to end, protecting the lines it spans.
+func (l *lexer) str(end int) {
+	l.literals = append(l.literals, [2]int{l.pos, end})
+	l.emit(end, true)
+	l.lastCode = l.src[end-1]
+	l.word = [2]int{}
+}
+
+// code copies code up to end, remembering its last word for hooks.
+func (l *lexer) code(end int) {
+	for i := l.pos; i < end; i++ {
+		b := l.src[i]
+		switch {
+		case isIdent(b):
+			if i == 0 || !isIdent(l.src[i-1]) {
+				l.word[0] = i
+			}
+			l.word[1] = i + 1
+			l.lastCode = b
+		case b != ' ' && b != '\t' && b != '\n' && b != '\r':
+			l.lastCode = b
+			l.word = [2]int{}
+		}
+	}
+	l.emit(end, false)
+}
+
+// lastWord returns the identifier the last code ended with, if any.
+func (l *lexer) lastWord() string {
+	return string(l.src[l.word[0]:l.word[1]])
+}
+
+// emit copies the source up to end.
+func (l *lexer) emit(end int, protect bool) {
+	for _, b := range l.src[l.pos:end] {
+		l.out.WriteByte(b)
+		if b == '\n' {
+			l.line++
+			if protect {
+				l.protected[l.line] = true
+			}
+		}
+	}
+	l.pos = end
+}
+
+// lineEnd returns the position of the newline ending the line at pos.
+func (l *lexer) lineEnd(pos int) int {
+	if i := bytes.IndexByte(l.src[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(l.src)
+}
+
+// lineStart returns the position of the start of the line at pos.
+func (l *lexer) lineStart(pos int) int {
+	return bytes.LastIndexByte(l.src[:pos], '\n') + 1
+}
+
+// atLineStart reports whether only spaces precede pos on its line.
+func (l *lexer) atLineStart(pos int) bool {
+	return len(bytes.TrimLeft(l.src[l.lineStart(pos):pos], " \t")) == 0
+}
+
+func isIdent(b byte) bool {
+	return b == '_' || b == '$' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9'
+}
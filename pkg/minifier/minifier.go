@@ -0,0 +1,109 @@
+// File: pkg/minifier/minifier.go
+
+package minifier
+
+import (
+	"bytes"
+)
+
+// Options selects what Minify removes.
+type Options struct {
+	StripComments      bool
+	CollapseBlankLines bool
+	// KeepDocComments keeps doc comments when StripComments is set: Go
+	// comments on top-level declarations, /** */ and /// comments in C-family
+	// languages and Python docstrings.
+	KeepDocComments bool
+}
+
+// Enabled reports whether any option is set.
+func (o Options) Enabled() bool {
+	return o.StripComments || o.CollapseBlankLines
+}
+
+// Minify removes comments and collapses blank lines in the source of a
+// language detected by the languagedetector package. Comment markers inside
+// string literals are left alone, and so are the lines of multi-line strings.
+// Comments of unsupported languages are kept.
+func Minify(language string, content []byte, opts Options) []byte {
+	l := newLexer(language, content, opts)
+	if l.syntax != nil {
+		l.run()
+	} else {
+		l.out.Write(content)
+	}
+	return l.finish(bytes.HasSuffix(content, []byte("\n")))
+}
+
+// Code reports for each byte of content whether it is code rather than part
+// of a comment or string literal, for the languages Minify supports. Bytes
+// of other languages are all code.
+func Code(language string, content []byte) []bool {
+	code := make([]bool, len(content))
+	for i := range code {
+		code[i] = true
+	}
+
+	l := newLexer(language, content, Options{})
+	if l.syntax == nil {
+		return code
+	}
+	l.run()
+
+	for _, literal := range l.literals {
+		for i := literal[0]; i < literal[1]; i++ {
+			code[i] = false
+		}
+	}
+	return code
+}
+
+// finish drops the lines left empty by removed comments and, if asked,
+// collapses runs of blank lines. Lines that start inside a string literal
+// are kept as they are.
+func (l *lexer) finish(finalNewline bool) []byte {
+	lines := bytes.Split(l.out.Bytes(), []byte("\n"))
+	if finalNewline && len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	var kept [][]byte
+	blank := false
+	for i, line := range lines {
+		if l.protected[i] {
+			if l.touched[i] {
+				line = bytes.TrimRight(line, " \t\r")
+			}
+			kept = append(kept, line)
+			blank = false
+			continue
+		}
+
+		if l.touched[i] || l.opts.CollapseBlankLines {
+			line = bytes.TrimRight(line, " \t\r")
+			if len(line) == 0 && l.touched[i] {
+				continue
+			}
+		}
+
+		if l.opts.CollapseBlankLines && len(line) == 0 {
+			if blank || len(kept) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		kept = append(kept, line)
+	}
+
+	if l.opts.CollapseBlankLines && blank {
+		kept = kept[:len(kept)-1]
+	}
+
+	result := bytes.Join(kept, []byte("\n"))
+	if finalNewline && len(kept) > 0 {
+		result = append(result, '\n')
+	}
+	return result
+}
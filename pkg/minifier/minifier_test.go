@@ -0,0 +1,148 @@
+// File: pkg/minifier/minifier_test.go
+
+package minifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMinify(t *testing.T) {
+	strip := Options{StripComments: true}
+	keepDocs := Options{StripComments: true, KeepDocComments: true}
+
+	tests := []struct {
+		name     string
+		language string
+		opts     Options
+		input    string
+		expected string
+	}{
+		{
+			name:     "Go comments",
+			language: "go",
+			opts:     strip,
+			input:    "package main\n\n// Greet says hello.\nfunc Greet() string {\n\t// inline\n\treturn \"// not a comment\" /* block */ + `/* raw */`\n}\n",
+			expected: "package main\n\nfunc Greet() string {\n\treturn \"// not a comment\"  + `/* raw */`\n}\n",
+		},
+		{
+			name:     "Go doc comments kept",
+			language: "go",
+			opts:     keepDocs,
+			input:    "// Package main is an example.\npackage main\n\n// Greet says hello.\n// It is exported.\nfunc Greet() {\n\t// inline\n}\n\n// loose comment\n\nvar x = 1\n",
+			expected: "// Package main is an example.\npackage main\n\n// Greet says hello.\n// It is exported.\nfunc Greet() {\n}\n\n\nvar x = 1\n",
+		},
+		{
+			name:     "Go directives kept",
+			language: "go",
+			opts:     strip,
+			input:    "//go:build linux\n\npackage main\n\n//go:embed static\nvar static string // the files\n",
+			expected: "//go:build linux\n\npackage main\n\n//go:embed static\nvar static string\n",
+		},
+		{
+			name:     "Go block comment keeps line breaks",
+			language: "go",
+			opts:     strip,
+			input:    "x := 1 /* a\nb */ y := 2\n",
+			expected: "x := 1\n y := 2\n",
+		},
+		{
+			name:     "Go rune literals",
+			language: "go",
+			opts:     strip,
+			input:    "q := '\"' // quote\ns := \"/*\" // open\n",
+			expected: "q := '\"'\ns := \"/*\"\n",
+		},
+		{
+			name:     "JavaScript regex and template literals",
+			language: "js",
+			opts:     strip,
+			input:    "const re = /\\/*$/g; // trailing\nconst t = `a ${b /* c */ + `// ${d}`} // e`;\nconst half = x / 2; // f\n",
+			expected: "const re = /\\/*$/g;\nconst t = `a ${b  + `// ${d}`} // e`;\nconst half = x / 2;\n",
+		},
+		{
+			name:     "JSDoc kept",
+			language: "ts",
+			opts:     keepDocs,
+			input:    "/** Adds. */\nfunction add(a: number) { /* no */ return a }\n/// <reference path=\"x\" />\n",
+			expected: "/** Adds. */\nfunction add(a: number) {  return a }\n/// <reference path=\"x\" />\n",
+		},
+		{
+			name:     "C++ raw strings",
+			language: "cpp",
+			opts:     strip,
+			input:    "auto s = R\"x(// not \" a comment)x\"; // comment\n",
+			expected: "auto s = R\"x(// not \" a comment)x\";\n",
+		},
+		{
+			name:     "Java text blocks",
+			language: "java",
+			opts:     strip,
+			input:    "String s = \"\"\"\n  // text\n  \"\"\"; // end\n",
+			expected: "String s = \"\"\"\n  // text\n  \"\"\";\n",
+		},
+		{
+			name:     "Python comments and docstrings",
+			language: "python",
+			opts:     strip,
+			input:    "#!/usr/bin/env python3\ndef f():\n    \"\"\"Docs.\n\n    More.\n    \"\"\"\n    s = '# not' # comment\n    return r\"\"\"# raw\"\"\"\n",
+			expected: "#!/usr/bin/env python3\ndef f():\n    s = '# not'\n    return r\"\"\"# raw\"\"\"\n",
+		},
+		{
+			name:     "Python docstrings kept",
+			language: "python",
+			opts:     keepDocs,
+			input:    "def f():\n    \"\"\"Docs.\"\"\"\n    # comment\n    pass\n",
+			expected: "def f():\n    \"\"\"Docs.\"\"\"\n    pass\n",
+		},
+		{
+			name:     "Shell comments",
+			language: "shell",
+			opts:     strip,
+			input:    "#!/bin/sh\n# comment\necho \"#1\" '#2' ${#arr} $# a#b # end\ncat <<EOF\n# kept\nEOF\necho done\n",
+			expected: "#!/bin/sh\necho \"#1\" '#2' ${#arr} $# a#b\ncat <<EOF\n# kept\nEOF\necho done\n",
+		},
+		{
+			name:     "YAML comments",
+			language: "yaml",
+			opts:     strip,
+			input:    "# header\nname: it's # comment\ncolor: \"#fff\" # hex\nscript: |\n  # kept\n  echo hi\nurl: http://x/#anchor\n",
+			expected: "name: it's\ncolor: \"#fff\"\nscript: |\n  # kept\n  echo hi\nurl: http://x/#anchor\n",
+		},
+		{
+			name:     "Collapse blank lines",
+			language: "go",
+			opts:     Options{CollapseBlankLines: true},
+			input:    "\n\npackage main  \n\n\n\nfunc main() {\n\ts := `a\n\n\n\tb`\n}\n\n",
+			expected: "package main\n\nfunc main() {\n\ts := `a\n\n\n\tb`\n}\n",
+		},
+		{
+			name:     "Unsupported language",
+			language: "ruby",
+			opts:     strip,
+			input:    "# comment\nputs 1\n",
+			expected: "# comment\nputs 1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(Minify(tt.language, []byte(tt.input), tt.opts)))
+		})
+	}
+}
+
+func TestCode(t *testing.T) {
+	content := "x := \"{\" // }\ny := 1"
+	code := Code("go", []byte(content))
+
+	var codeOnly []byte
+	for i, isCode := range code {
+		if isCode {
+			codeOnly = append(codeOnly, content[i])
+		}
+	}
+	assert.Equal(t, "x :=  \ny := 1", string(codeOnly))
+	assert.Equal(t, []bool{true, true}, Code("ruby", []byte("#x")))
+}
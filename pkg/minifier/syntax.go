@@ -0,0 +1,505 @@
+// File: pkg/minifier/syntax.go
+
+package minifier
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// syntax describes the comments and string literals of a language.
+type syntax struct {
+	lineComments []string
+	blockComment [2]string
+	// quotes start string literals that end with the same byte. Quotes in
+	// rawQuotes have no backslash escapes.
+	quotes           string
+	rawQuotes        string
+	multilineStrings bool
+
+	// quoteStart and commentStart, if set, report whether a quote or line
+	// comment marker at the lexer's position really starts one.
+	quoteStart   func(l *lexer) bool
+	commentStart func(l *lexer) bool
+	// keep reports whether a comment is always kept, like a directive.
+	keep func(src []byte, start, end int) bool
+	// doc reports whether a comment is a doc comment.
+	doc func(src []byte, start, end int) bool
+	// special handles the language's other literals at the lexer's position
+	// and reports whether it consumed any input.
+	special func(l *lexer) bool
+}
+
+var syntaxes = map[string]*syntax{
+	"go":       goSyntax,
+	"js":       jsSyntax,
+	"ts":       jsSyntax,
+	"c":        cSyntax,
+	"cpp":      cppSyntax,
+	"java":     javaSyntax,
+	"csharp":   javaSyntax,
+	"python":   pythonSyntax,
+	"starlark": pythonSyntax,
+	"shell":    shellSyntax,
+	"yaml":     yamlSyntax,
+}
+
+var goSyntax = &syntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `"'`,
+	keep:         goDirective,
+	doc:          goDoc,
+	special: func(l *lexer) bool {
+		if l.src[l.pos] != '`' {
+			return false
+		}
+		l.delimited(l.pos+1, "`")
+		return true
+	},
+}
+
+var cSyntax = &syntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `"'`,
+	doc:          cDoc,
+}
+
+var cppSyntax = &syntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `"'`,
+	doc:          cDoc,
+	special:      cppRawString,
+}
+
+// javaSyntax also covers C#: """ text blocks and @"..." verbatim strings.
+var javaSyntax = &syntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `"'`,
+	doc:          cDoc,
+	special: func(l *lexer) bool {
+		rest := l.src[l.pos:]
+		switch {
+		case bytes.HasPrefix(rest, []byte(`"""`)):
+			l.delimited(l.pos+3, `"""`)
+		case bytes.HasPrefix(rest, []byte(`@"`)):
+			l.verbatim(l.pos + 2)
+		default:
+			return false
+		}
+		return true
+	},
+}
+
+var jsSyntax = &syntax{
+	lineComments: []string{"//"},
+	blockComment: [2]string{"/*", "*/"},
+	quotes:       `"'`,
+	keep: func(src []byte, start, end int) bool {
+		comment := src[start:end]
+		return bytes.HasPrefix(comment, []byte("/// <reference")) || bytes.HasPrefix(comment, []byte("// @ts-"))
+	},
+	doc:     cDoc,
+	special: jsLiteral,
+}
+
+var pythonSyntax = &syntax{
+	lineComments: []string{"#"},
+	quotes:       `"'`,
+	keep: func(src []byte, start, end int) bool {
+		return isShebang(src, start) || pythonCoding.Match(src[start:end]) && bytes.Count(src[:start], []byte("\n")) < 2
+	},
+	// Comments are never doc comments; docstrings are handed to comment by
+	// pythonString.
+	doc: func(src []byte, start, end int) bool {
+		return src[start] != '#'
+	},
+	special: pythonString,
+}
+
+var shellSyntax = &syntax{
+	lineComments:     []string{"#"},
+	quotes:           `"'`,
+	rawQuotes:        `'`,
+	multilineStrings: true,
+	commentStart: func(l *lexer) bool {
+		return l.pos == 0 || strings.IndexByte(" \t\n;&|(", l.src[l.pos-1]) >= 0
+	},
+	keep: func(src []byte, start, end int) bool {
+		return isShebang(src, start)
+	},
+	special: shellLiteral,
+}
+
+var yamlSyntax = &syntax{
+	lineComments:     []string{"#"},
+	quotes:           `"'`,
+	rawQuotes:        `'`,
+	multilineStrings: true,
+	commentStart: func(l *lexer) bool {
+		return l.pos == 0 || strings.IndexByte(" \t\n", l.src[l.pos-1]) >= 0
+	},
+	// A quote only starts a string at the start of a scalar, so apostrophes
+	// in plain scalars are not strings. Two single quotes are an escape.
+	quoteStart: func(l *lexer) bool {
+		if l.pos > 0 && l.src[l.pos-1] == l.src[l.pos] {
+			return true
+		}
+		before := bytes.TrimRight(l.src[l.lineStart(l.pos):l.pos], " \t")
+		return len(before) == 0 || strings.IndexByte(":-[{,?", before[len(before)-1]) >= 0
+	},
+	special: yamlBlockScalar,
+}
+
+var (
+	goDirectives  = []string{"//go:", "//line ", "//export ", "//extern ", "// +build"}
+	goDeclaration = regexp.MustCompile(`^(func|type|var|const|package)\b`)
+	goImportC     = regexp.MustCompile(`^import\s+"C"`)
+	pythonCoding  = regexp.MustCompile(`^#.*?coding[:=]`)
+)
+
+// goDirective keeps compiler directives and cgo preambles.
+func goDirective(src []byte, start, end int) bool {
+	for _, directive := range goDirectives {
+		if bytes.HasPrefix(src[start:], []byte(directive)) {
+			return true
+		}
+	}
+	return goImportC.Match(goFollowing(src, end))
+}
+
+// goDoc reports whether a comment is part of the comment group directly
+// preceding a top-level declaration.
+func goDoc(src []byte, start, end int) bool {
+	if start > 0 && src[start-1] != '\n' {
+		return false
+	}
+	return goDeclaration.Match(goFollowing(src, end))
+}
+
+// goFollowing returns the source from the first line after a comment that
+// is not a line comment.
+func goFollowing(src []byte, end int) []byte {
+	rest := src[end:]
+	for {
+		i := bytes.IndexByte(rest, '\n')
+		if i < 0 {
+			return nil
+		}
+		rest = rest[i+1:]
+		if !bytes.HasPrefix(rest, []byte("//")) {
+			return rest
+		}
+	}
+}
+
+// cDoc reports whether a comment is a Javadoc, JSDoc or Doxygen comment.
+func cDoc(src []byte, start, end int) bool {
+	comment := src[start:end]
+	switch {
+	case bytes.HasPrefix(comment, []byte("/**")):
+		return !bytes.HasPrefix(comment, []byte("/**/"))
+	case bytes.HasPrefix(comment, []byte("///")):
+		return !bytes.HasPrefix(comment, []byte("////"))
+	default:
+		return false
+	}
+}
+
+func isShebang(src []byte, start int) bool {
+	return start == 0 && bytes.HasPrefix(src, []byte("#!"))
+}
+
+// cppRawString handles raw strings such as R"(...)" and u8R"x(...)x".
+func cppRawString(l *lexer) bool {
+	if !bytes.HasPrefix(l.src[l.pos:], []byte(`R"`)) {
+		return false
+	}
+	if l.pos > 0 && isIdent(l.src[l.pos-1]) && !strings.Contains(" u8 u U L ", " "+l.lastWord()+" ") {
+		return false
+	}
+
+	open := bytes.IndexByte(l.src[l.pos:], '(')
+	if open < 0 {
+		return false
+	}
+	delimiter := l.src[l.pos+2 : l.pos+open]
+	l.delimited(l.pos+open+1, ")"+string(delimiter)+`"`)
+	return true
+}
+
+// verbatim copies a C# verbatim string starting at start, in which two
+// double quotes are an escaped quote.
+func (l *lexer) verbatim(start int) {
+	for i := start; i < len(l.src); i++ {
+		if l.src[i] != '"' {
+			continue
+		}
+		if i+1 < len(l.src) && l.src[i+1] == '"' {
+			i++
+			continue
+		}
+		l.str(i + 1)
+		return
+	}
+	l.str(len(l.src))
+}
+
+// jsKeywords are the keywords after which a slash starts a regular
+// expression rather than a division.
+var jsKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "in": true, "of": true, "new": true,
+	"delete": true, "void": true, "throw": true, "case": true, "do": true, "else": true,
+	"yield": true, "await": true,
+}
+
+// jsLiteral handles template literals, including the code of their ${...}
+// substitutions, and regular expression literals.
+func jsLiteral(l *lexer) bool {
+	depth := len(l.templates) - 1
+
+	switch c := l.src[l.pos]; {
+	case c == '`':
+		l.template(l.pos + 1)
+	case c == '{' && depth >= 0:
+		l.templates[depth]++
+		l.code(l.pos + 1)
+	case c == '}' && depth >= 0:
+		if l.templates[depth] > 0 {
+			l.templates[depth]--
+			l.code(l.pos + 1)
+			return true
+		}
+		l.templates = l.templates[:depth]
+		l.template(l.pos + 1)
+	case c == '/':
+		return l.regexp()
+	default:
+		return false
+	}
+	return true
+}
+
+// template copies a template literal from its opening backtick or the
+// closing brace of a substitution, up to its closing backtick or the
+// opening ${ of the next substitution.
+func (l *lexer) template(start int) {
+	for i := start; i < len(l.src); i++ {
+		switch {
+		case l.src[i] == '\\':
+			i++
+		case l.src[i] == '`':
+			l.str(i + 1)
+			return
+		case l.src[i] == '$' && i+1 < len(l.src) && l.src[i+1] == '{':
+			l.str(i + 2)
+			l.templates = append(l.templates, 0)
+			return
+		}
+	}
+	l.str(len(l.src))
+}
+
+// regexp copies a regular expression literal if a slash at the lexer's
+// position starts one.
+func (l *lexer) regexp() bool {
+	if next := l.src[min(l.pos+1, len(l.src)-1)]; next == '/' || next == '*' {
+		return false
+	}
+	if l.lastCode != 0 && strings.IndexByte("(,=:[!&|?{};+-*%<>~^", l.lastCode) < 0 && !jsKeywords[l.lastWord()] {
+		return false
+	}
+
+	inClass := false
+	for i := l.pos + 1; i < len(l.src); i++ {
+		switch l.src[i] {
+		case '\\':
+			i++
+		case '\n':
+			return false
+		case '[':
+			inClass = true
+		case ']':
+			inClass = false
+		case '/':
+			if inClass {
+				continue
+			}
+			end := i + 1
+			for end < len(l.src) && isIdent(l.src[end]) {
+				end++
+			}
+			l.str(end)
+			return true
+		}
+	}
+	return false
+}
+
+// pythonString handles prefixed and triple-quoted strings. Docstrings,
+// triple-quoted strings alone on their lines, are treated as comments.
+func pythonString(l *lexer) bool {
+	i := l.pos
+	if l.pos == 0 || !isIdent(l.src[l.pos-1]) {
+		for i < len(l.src) && i-l.pos < 2 && strings.IndexByte("rRbBuUfF", l.src[i]) >= 0 {
+			i++
+		}
+	}
+	if i >= len(l.src) || l.src[i] != '"' && l.src[i] != '\'' {
+		return false
+	}
+
+	quote := l.src[i]
+	triple := bytes.Repeat([]byte{quote}, 3)
+	if !bytes.HasPrefix(l.src[i:], triple) {
+		l.quoted(i+1, quote, false, "")
+		return true
+	}
+
+	end := len(l.src)
+	for j := i + 3; j < len(l.src); j++ {
+		if l.src[j] == '\\' {
+			j++
+			continue
+		}
+		if bytes.HasPrefix(l.src[j:], triple) {
+			end = j + 3
+			break
+		}
+	}
+
+	after := bytes.TrimLeft(l.src[end:l.lineEnd(end)], " \t\r")
+	if l.atLineStart(l.pos) && (len(after) == 0 || after[0] == '#') {
+		l.comment(end)
+	} else {
+		l.str(end)
+	}
+	return true
+}
+
+// shellLiteral handles escapes, $'...' strings and here-documents.
+func shellLiteral(l *lexer) bool {
+	rest := l.src[l.pos:]
+	switch {
+	case rest[0] == '\\':
+		l.code(min(l.pos+2, len(l.src)))
+	case bytes.HasPrefix(rest, []byte("$'")):
+		l.code(l.pos + 1)
+		l.quoted(l.pos+1, '\'', true, "")
+	case rest[0] == '\n' && len(l.heredocs) > 0:
+		l.code(l.pos + 1)
+		l.heredocBodies()
+	case bytes.HasPrefix(rest, []byte("<<<")):
+		l.code(l.pos + 3)
+	case bytes.HasPrefix(rest, []byte("<<")):
+		l.heredoc()
+	default:
+		return false
+	}
+	return true
+}
+
+// heredoc parses a here-document redirection such as <<EOF, <<-EOF or
+// <<'EOF', whose body starts on the next line.
+func (l *lexer) heredoc() {
+	i := l.pos + 2
+	doc := heredoc{}
+	if i < len(l.src) && l.src[i] == '-' {
+		doc.stripTabs = true
+		i++
+	}
+	for i < len(l.src) && (l.src[i] == ' ' || l.src[i] == '\t') {
+		i++
+	}
+
+	start := i
+	if i < len(l.src) && (l.src[i] == '\'' || l.src[i] == '"') {
+		quote := l.src[i]
+		close := bytes.IndexByte(l.src[i+1:], quote)
+		if close < 0 {
+			l.code(l.pos + 2)
+			return
+		}
+		doc.delimiter = string(l.src[i+1 : i+1+close])
+		i += close + 2
+	} else {
+		for i < len(l.src) && strings.IndexByte(" \t\n;|&<>()", l.src[i]) < 0 {
+			i++
+		}
+		doc.delimiter = strings.ReplaceAll(string(l.src[start:i]), `\`, "")
+	}
+
+	// A digit is more likely a shift in arithmetic than a delimiter.
+	if doc.delimiter == "" || doc.delimiter[0] >= '0' && doc.delimiter[0] <= '9' {
+		l.code(l.pos + 2)
+		return
+	}
+	l.heredocs = append(l.heredocs, doc)
+	l.code(i)
+}
+
+// heredocBodies copies the bodies of the pending here-documents as they are.
+func (l *lexer) heredocBodies() {
+	for _, doc := range l.heredocs {
+		for l.pos < len(l.src) {
+			end := l.lineEnd(l.pos)
+			line := l.src[l.pos:end]
+			if doc.stripTabs {
+				line = bytes.TrimLeft(line, "\t")
+			}
+			if string(bytes.TrimRight(line, "\r")) == doc.delimiter {
+				l.emit(end, false)
+				break
+			}
+			l.protected[l.line] = true
+			l.emit(min(end+1, len(l.src)), false)
+		}
+	}
+	l.heredocs = nil
+}
+
+// yamlBlockScalar handles literal and folded block scalars, whose more
+// indented lines are copied as they are.
+func yamlBlockScalar(l *lexer) bool {
+	switch c := l.src[l.pos]; {
+	case c == '\n' && l.scalar >= 0:
+		l.code(l.pos + 1)
+		for l.pos < len(l.src) {
+			end := l.lineEnd(l.pos)
+			line := l.src[l.pos:end]
+			content := bytes.TrimLeft(line, " ")
+			if len(bytes.TrimSpace(line)) > 0 && len(line)-len(content) <= l.scalar {
+				break
+			}
+			l.protected[l.line] = true
+			l.emit(min(end+1, len(l.src)), false)
+		}
+		l.scalar = -1
+		return true
+	case c == '|' || c == '>':
+		start := l.lineStart(l.pos)
+		before := bytes.TrimRight(l.src[start:l.pos], " \t")
+		if len(before) > 0 && !bytes.HasSuffix(before, []byte(":")) && !bytes.HasSuffix(before, []byte("-")) && !bytes.HasSuffix(before, []byte("?")) {
+			return false
+		}
+
+		i := l.pos + 1
+		for i < len(l.src) && strings.IndexByte("+-0123456789", l.src[i]) >= 0 {
+			i++
+		}
+		after := bytes.TrimLeft(l.src[i:l.lineEnd(i)], " \t\r")
+		if len(after) > 0 && after[0] != '#' {
+			return false
+		}
+
+		l.scalar = len(l.src[start:l.pos]) - len(bytes.TrimLeft(l.src[start:l.pos], " "))
+		l.code(i)
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+// File: pkg/pathmatch/pathmatch.go
+
+package pathmatch
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// Matcher matches slash-separated relative paths against gitignore-style
+// globs. Patterns are evaluated in order and the last one that matches wins,
+// so a pattern starting with ! re-includes paths matched by earlier ones.
+//
+// As in .gitignore, a pattern without a slash matches at any depth, a
+// pattern with a leading or inner slash is anchored at the root, a trailing
+// slash only matches directories, and a pattern matching a directory matches
+// everything below it. ** matches any number of directories.
+type Matcher struct {
+	patterns []pattern
+}
+
+type pattern struct {
+	glob    string
+	negate  bool
+	dirOnly bool
+}
+
+// New compiles the patterns. Empty patterns are ignored.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for _, raw := range patterns {
+		p, ok, err := compile(raw)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			m.patterns = append(m.patterns, p)
+		}
+	}
+	return m, nil
+}
+
+// Empty reports whether the matcher has no patterns.
+func (m *Matcher) Empty() bool {
+	return len(m.patterns) == 0
+}
+
+// Match reports whether a slash-separated relative path is matched.
+func (m *Matcher) Match(relPath string) bool {
+	matched := false
+	for _, p := range m.patterns {
+		if p.match(relPath) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+func compile(raw string) (pattern, bool, error) {
+	glob := strings.TrimSpace(raw)
+
+	var p pattern
+	if strings.HasPrefix(glob, "!") {
+		p.negate = true
+		glob = glob[1:]
+	}
+	if strings.HasSuffix(glob, "/") {
+		p.dirOnly = true
+		glob = strings.TrimRight(glob, "/")
+	}
+	if glob == "" {
+		return p, false, nil
+	}
+
+	if strings.HasPrefix(glob, "/") {
+		glob = strings.TrimLeft(glob, "/")
+	} else if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+
+	if !doublestar.ValidatePattern(glob) {
+		return p, false, fmt.Errorf("invalid glob %q", raw)
+	}
+	p.glob = glob
+	return p, true, nil
+}
+
+func (p pattern) match(relPath string) bool {
+	if !p.dirOnly && matchGlob(p.glob, relPath) {
+		return true
+	}
+	for dir := path.Dir(relPath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if matchGlob(p.glob, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchGlob(glob, name string) bool {
+	matched, _ := doublestar.Match(glob, name)
+	return matched
+}
@@ -0,0 +1,49 @@
+// File: pkg/pathmatch/pathmatch_test.go
+
+package pathmatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		expected bool
+	}{
+		{"Base name at any depth", []string{"test"}, "pkg/test/a.go", true},
+		{"No substring match", []string{"test"}, "latest.go", false},
+		{"No substring match in directory", []string{"test"}, "contest/a.go", false},
+		{"Double star suffix", []string{"**/*_test.go"}, "pkg/a/a_test.go", true},
+		{"Double star suffix at root", []string{"**/*_test.go"}, "a_test.go", true},
+		{"Anchored directory", []string{"vendor/**"}, "vendor/x/y.go", true},
+		{"Anchored directory elsewhere", []string{"vendor/**"}, "pkg/vendor/y.go", false},
+		{"Directory matches contents", []string{"docs/api"}, "docs/api/index.md", true},
+		{"Leading slash anchors", []string{"/main.go"}, "cmd/main.go", false},
+		{"Leading slash at root", []string{"/main.go"}, "main.go", true},
+		{"Trailing slash matches directories only", []string{"build/"}, "build", false},
+		{"Trailing slash matches directory contents", []string{"build/"}, "out/build/a.o", true},
+		{"Negation re-includes", []string{"vendor/**", "!vendor/keep/**"}, "vendor/keep/a.go", false},
+		{"Negation leaves others", []string{"vendor/**", "!vendor/keep/**"}, "vendor/drop/a.go", true},
+		{"Last match wins", []string{"!*.go", "*.go"}, "a.go", true},
+		{"No patterns", nil, "a.go", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.patterns)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, m.Match(tt.path))
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	_, err := New([]string{"[a-"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,63 @@
+package tiktoken
+
+import "math"
+
+const noRank = math.MaxInt
+
+// bytePairEncode appends the tokens for a single pre-tokenized piece to out by
+// repeatedly merging the adjacent pair with the lowest rank.
+func (e *Encoding) bytePairEncode(piece string, out []int) []int {
+	if len(piece) == 1 {
+		return append(out, e.ranks[piece])
+	}
+
+	type part struct {
+		start int
+		rank  int // rank of merging this part with the next one
+	}
+
+	parts := make([]part, 0, len(piece)+1)
+	for i := 0; i < len(piece)-1; i++ {
+		parts = append(parts, part{start: i, rank: e.rank(piece[i : i+2])})
+	}
+	parts = append(parts, part{start: len(piece) - 1, rank: noRank}, part{start: len(piece), rank: noRank})
+
+	// mergedRank returns the rank of parts[i] joined with parts[i+1] after
+	// parts[i+1] and parts[i+2] have already been merged.
+	mergedRank := func(i int) int {
+		if i+3 < len(parts) {
+			return e.rank(piece[parts[i].start:parts[i+3].start])
+		}
+		return noRank
+	}
+
+	for {
+		minIdx, minRank := -1, noRank
+		for i := 0; i < len(parts)-1; i++ {
+			if parts[i].rank < minRank {
+				minIdx, minRank = i, parts[i].rank
+			}
+		}
+		if minIdx < 0 {
+			break
+		}
+
+		if minIdx > 0 {
+			parts[minIdx-1].rank = mergedRank(minIdx - 1)
+		}
+		parts[minIdx].rank = mergedRank(minIdx)
+		parts = append(parts[:minIdx+1], parts[minIdx+2:]...)
+	}
+
+	for i := 0; i < len(parts)-1; i++ {
+		out = append(out, e.ranks[piece[parts[i].start:parts[i+1].start]])
+	}
+	return out
+}
+
+func (e *Encoding) rank(b string) int {
+	if rank, ok := e.ranks[b]; ok {
+		return rank
+	}
+	return noRank
+}
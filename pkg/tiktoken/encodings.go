@@ -0,0 +1,178 @@
+package tiktoken
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	CL100kBase      = "cl100k_base"
+	O200kBase       = "o200k_base"
+	DefaultEncoding = CL100kBase
+)
+
+//go:embed assets/cl100k_base.tiktoken
+var cl100kBaseRanks []byte
+
+//go:embed assets/o200k_base.tiktoken
+var o200kBaseRanks []byte
+
+// whitespace is the Unicode White_Space property, which is what \s means in
+// the upstream patterns. RE2's \s only covers ASCII.
+const whitespace = `\t\n\v\f\r \x{85}\x{A0}\x{1680}\x{2000}-\x{200A}\x{2028}\x{2029}\x{202F}\x{205F}\x{3000}`
+
+// The upstream patterns end in `\s+(?!\S)|\s+`. RE2 has no lookahead, so the
+// last alternative is captured as a group and the lookahead is applied in
+// Encoding.split instead.
+var (
+	cl100kBasePattern = strings.NewReplacer("WS", whitespace).Replace(
+		`(?i:'s|'t|'re|'ve|'m|'ll|'d)` +
+			`|[^\r\n\p{L}\p{N}]?\p{L}+` +
+			`|\p{N}{1,3}` +
+			`| ?[^WS\p{L}\p{N}]+[\r\n]*` +
+			`|[WS]*[\r\n]+` +
+			`|([WS]+)`)
+
+	o200kBasePattern = strings.NewReplacer("WS", whitespace).Replace(
+		`[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+			`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+			`|\p{N}{1,3}` +
+			`| ?[^WS\p{L}\p{N}]+[\r\n/]*` +
+			`|[WS]*[\r\n]+` +
+			`|([WS]+)`)
+)
+
+type encodingSpec struct {
+	ranks         []byte
+	pattern       string
+	specialTokens map[string]int
+}
+
+var encodingSpecs = map[string]encodingSpec{
+	CL100kBase: {
+		ranks:   cl100kBaseRanks,
+		pattern: cl100kBasePattern,
+		specialTokens: map[string]int{
+			"<|endoftext|>":   100257,
+			"<|fim_prefix|>":  100258,
+			"<|fim_middle|>":  100259,
+			"<|fim_suffix|>":  100260,
+			"<|endofprompt|>": 100276,
+		},
+	},
+	O200kBase: {
+		ranks:   o200kBaseRanks,
+		pattern: o200kBasePattern,
+		specialTokens: map[string]int{
+			"<|endoftext|>":   199999,
+			"<|endofprompt|>": 200018,
+		},
+	},
+}
+
+var (
+	encodingsMu sync.Mutex
+	encodings   = make(map[string]*Encoding)
+)
+
+// Encodings returns the names of all supported encodings.
+func Encodings() []string {
+	names := make([]string, 0, len(encodingSpecs))
+	for name := range encodingSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetEncoding returns the named encoding, loading its rank file on first use.
+// An empty name selects DefaultEncoding.
+func GetEncoding(name string) (*Encoding, error) {
+	if name == "" {
+		name = DefaultEncoding
+	}
+
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+
+	if enc, ok := encodings[name]; ok {
+		return enc, nil
+	}
+
+	spec, ok := encodingSpecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding %q (supported: %s)", name, strings.Join(Encodings(), ", "))
+	}
+
+	enc, err := newEncoding(name, spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encoding %s: %w", name, err)
+	}
+
+	encodings[name] = enc
+	return enc, nil
+}
+
+func newEncoding(name string, spec encodingSpec) (*Encoding, error) {
+	ranks, err := parseRanks(spec.ranks)
+	if err != nil {
+		return nil, err
+	}
+
+	pattern, err := regexp.Compile(spec.pattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile pattern: %w", err)
+	}
+
+	decoder := make(map[int]string, len(ranks)+len(spec.specialTokens))
+	for token, rank := range ranks {
+		decoder[rank] = token
+	}
+	for token, rank := range spec.specialTokens {
+		decoder[rank] = token
+	}
+
+	return &Encoding{
+		name:    name,
+		ranks:   ranks,
+		decoder: decoder,
+		pattern: pattern,
+	}, nil
+}
+
+// parseRanks reads a .tiktoken rank file: one base64 token and its rank per line.
+func parseRanks(data []byte) (map[string]int, error) {
+	ranks := make(map[string]int, bytes.Count(data, []byte("\n")))
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		encoded, rankStr, ok := bytes.Cut(line, []byte(" "))
+		if !ok {
+			return nil, fmt.Errorf("malformed rank on line %d", i+1)
+		}
+
+		token, err := base64.StdEncoding.DecodeString(string(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid token on line %d: %w", i+1, err)
+		}
+
+		rank, err := strconv.Atoi(string(rankStr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rank on line %d: %w", i+1, err)
+		}
+
+		ranks[string(token)] = rank
+	}
+
+	return ranks, nil
+}
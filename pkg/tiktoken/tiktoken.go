@@ -1,14 +1,110 @@
 package tiktoken
 
 import (
+	"fmt"
+	"regexp"
 	"strings"
-	"unicode"
+	"unicode/utf8"
 )
 
-func CountTokens(s string) int {
-	words := strings.FieldsFunc(s, func(r rune) bool {
-		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+// Encoding is a byte-pair encoding compatible with OpenAI's tiktoken.
+type Encoding struct {
+	name    string
+	ranks   map[string]int
+	decoder map[int]string
+	pattern *regexp.Regexp
+}
+
+func (e *Encoding) Name() string {
+	return e.name
+}
+
+// Encode converts text to tokens. Special tokens such as <|endoftext|> are
+// encoded as ordinary text.
+func (e *Encoding) Encode(text string) []int {
+	var tokens []int
+	e.split(text, func(piece string) {
+		if rank, ok := e.ranks[piece]; ok {
+			tokens = append(tokens, rank)
+			return
+		}
+		tokens = e.bytePairEncode(piece, tokens)
+	})
+	return tokens
+}
+
+func (e *Encoding) Decode(tokens []int) (string, error) {
+	var sb strings.Builder
+	for _, token := range tokens {
+		piece, ok := e.decoder[token]
+		if !ok {
+			return "", fmt.Errorf("invalid token for %s: %d", e.name, token)
+		}
+		sb.WriteString(piece)
+	}
+	return sb.String(), nil
+}
+
+func (e *Encoding) Count(text string) int {
+	count := 0
+	e.split(text, func(piece string) {
+		if _, ok := e.ranks[piece]; ok {
+			count++
+			return
+		}
+		count += len(e.bytePairEncode(piece, nil))
 	})
+	return count
+}
+
+// splitWindow bounds how much text the pre-tokenizer looks at per match.
+// Go's regexp only uses its fast backtracking matcher on short inputs, and
+// every piece except long runs of letters, symbols or spaces fits easily.
+const splitWindow = 256
+
+// splitMargin is how far past a match the pattern may need to look before it
+// can settle on that match, e.g. the optional "'ll" suffix in o200k_base.
+const splitMargin = 8
+
+// split runs the pre-tokenizer over text, emulating the `\s+(?!\S)` lookahead
+// of the upstream patterns: a whitespace run followed by a non-space character
+// gives its last character back so it can prefix the next piece.
+func (e *Encoding) split(text string, fn func(piece string)) {
+	for pos := 0; pos < len(text); {
+		loc := e.match(text[pos:])
+		if loc == nil || loc[1] == 0 {
+			return
+		}
+
+		start, end := pos+loc[0], pos+loc[1]
+		if loc[2] >= 0 && end < len(text) {
+			if _, size := utf8.DecodeLastRuneInString(text[start:end]); end-size > start {
+				end -= size
+			}
+		}
 
-	return len(words) + (len(s) / 10)
+		fn(text[start:end])
+		pos = end
+	}
+}
+
+// match finds the next piece in text, first within a short window and only
+// falling back to the whole text when the piece might extend past it.
+func (e *Encoding) match(text string) []int {
+	if len(text) > splitWindow {
+		if loc := e.pattern.FindStringSubmatchIndex(text[:splitWindow]); loc != nil && loc[1] <= splitWindow-splitMargin {
+			return loc
+		}
+	}
+	return e.pattern.FindStringSubmatchIndex(text)
+}
+
+// CountTokens counts the tokens in s using DefaultEncoding.
+func CountTokens(s string) int {
+	enc, err := GetEncoding(DefaultEncoding)
+	if err != nil {
+		// The rank files are embedded, so this only fails on a broken build.
+		panic(err)
+	}
+	return enc.Count(s)
 }
@@ -0,0 +1,80 @@
+// File: pkg/tiktoken/tiktoken_test.go
+
+package tiktoken
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		text     string
+		expected []int
+	}{
+		{"cl100k simple", CL100kBase, "hello world", []int{15339, 1917}},
+		{"cl100k code", CL100kBase, "func main() {\n\tfmt.Println(\"hi\")\n}\n", []int{2900, 1925, 368, 341, 11254, 12701, 446, 6151, 1158, 534}},
+		{"cl100k whitespace run", CL100kBase, "x  = 1", []int{87, 220, 284, 220, 16}},
+		{"cl100k multibyte", CL100kBase, "I'll say 日本語", []int{40, 3358, 2019, 76502, 22656, 45918, 252}},
+		{"cl100k special token as text", CL100kBase, "<|endoftext|>", []int{27, 91, 8862, 728, 428, 91, 29}},
+		{"o200k simple", O200kBase, "hello world", []int{24912, 2375}},
+		{"o200k code", O200kBase, "func main() {\n\tfmt.Println(\"hi\")\n}\n", []int{5652, 2758, 416, 405, 24728, 28250, 568, 3686, 1896, 739}},
+		{"o200k whitespace run", O200kBase, "x  = 1", []int{87, 220, 314, 220, 16}},
+		{"o200k contraction", O200kBase, "I'll say 日本語", []int{67504, 2891, 17428, 40909}},
+		{"empty", CL100kBase, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := GetEncoding(tt.encoding)
+			require.NoError(t, err)
+
+			tokens := enc.Encode(tt.text)
+			assert.Equal(t, tt.expected, tokens)
+			assert.Equal(t, len(tt.expected), enc.Count(tt.text))
+
+			decoded, err := enc.Decode(tokens)
+			require.NoError(t, err)
+			assert.Equal(t, tt.text, decoded)
+		})
+	}
+}
+
+func TestEncodeLongInput(t *testing.T) {
+	enc, err := GetEncoding(O200kBase)
+	require.NoError(t, err)
+
+	// Pieces longer than the split window must still round-trip.
+	text := strings.Repeat("a", 1000) + strings.Repeat(" ", 600) + "x" + strings.Repeat("I'll ", 200)
+	decoded, err := enc.Decode(enc.Encode(text))
+	require.NoError(t, err)
+	assert.Equal(t, text, decoded)
+}
+
+func TestGetEncoding(t *testing.T) {
+	enc, err := GetEncoding("")
+	require.NoError(t, err)
+	assert.Equal(t, DefaultEncoding, enc.Name())
+
+	_, err = GetEncoding("p50k_base")
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{CL100kBase, O200kBase}, Encodings())
+}
+
+func TestDecodeInvalidToken(t *testing.T) {
+	enc, err := GetEncoding(CL100kBase)
+	require.NoError(t, err)
+
+	_, err = enc.Decode([]int{-1})
+	assert.Error(t, err)
+}
+
+func TestCountTokens(t *testing.T) {
+	assert.Equal(t, 2, CountTokens("hello world"))
+}
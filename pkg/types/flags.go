@@ -1,7 +1,10 @@
 // File: pkg/types/flags.go
 package types
 
+import "time"
+
 type Flags struct {
+	Roots          []string
 	OutputFile     string
 	UseGitIgnore   bool
 	Languages      string
@@ -9,4 +12,62 @@ type Flags struct {
 	Verbose        bool
 	ExcludePattern string
 	ExcludePaths   []string
+	IncludePaths   []string
+	// SubstringExclude matches ExcludePaths as substrings instead of globs.
+	SubstringExclude bool
+	Encoding         string
+	Budget           *int
+	PackStrategy     string
+	PriorityGlobs    []string
+	ChunkTokens      *int
+	Format           string
+	Since            string
+	Staged           bool
+	Commit           string
+	IncludeDiff      bool
+	Rev              string
+	Profile          string
+	// IncludeGenerated keeps generated and minified files and lockfiles.
+	IncludeGenerated bool
+	// IncludeVendored keeps files in vendored directories like node_modules.
+	IncludeVendored bool
+	// StripComments, CollapseBlankLines and KeepDocComments select the
+	// language-aware minifier.
+	StripComments      bool
+	CollapseBlankLines bool
+	KeepDocComments    bool
+	// Signatures reduces files of the languages with a skeletonizer (Go,
+	// Python, JS/TS and Java) to their declarations and doc comments.
+	Signatures bool
+	// Oversize is what to do with files over MaxTokens: exclude, head,
+	// head-tail or outline.
+	Oversize string
+	// Order is the order of the files in the export: path, size, tokens,
+	// mtime or dependency.
+	Order string
+	// TreeAnnotate adds each file's size and token count to the tree.
+	TreeAnnotate bool
+	// TreeOnly writes only the directory structure.
+	TreeOnly bool
+	// TreeFiltered shows the files left out by .gitignore, the filters and
+	// the classifier in the tree.
+	TreeFiltered bool
+	// Jobs is the number of files processed at once, by default the number
+	// of CPUs.
+	Jobs *int
+	// ReadTimeout bounds the time to read a file from disk; zero selects the
+	// default.
+	ReadTimeout time.Duration
+	// Strict fails the export, without writing it, when any file could not
+	// be read or processed.
+	Strict bool
+}
+
+// StatsFlags are the flags of the stats subcommand.
+type StatsFlags struct {
+	Roots        []string
+	UseGitIgnore bool
+	Encoding     string
+	Format       string
+	Verbose      bool
 }